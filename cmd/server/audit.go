@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"transaction-api/internal/audit"
+	"transaction-api/internal/config"
+	"transaction-api/internal/database"
+	"transaction-api/internal/repository"
+	"transaction-api/internal/services"
+)
+
+const auditUsage = "usage: audit verify [--head SEQUENCE:HASH]..."
+
+// headFlags collects the repeatable --head flag
+type headFlags []audit.Head
+
+func (h *headFlags) String() string {
+	return fmt.Sprint(*h)
+}
+
+func (h *headFlags) Set(value string) error {
+	head, err := audit.ParseHead(value)
+	if err != nil {
+		return err
+	}
+	*h = append(*h, head)
+	return nil
+}
+
+// runAudit executes the audit subcommand
+func runAudit(cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "verify" {
+		return fmt.Errorf(auditUsage)
+	}
+
+	var heads headFlags
+	fs := flag.NewFlagSet("audit verify", flag.ContinueOnError)
+	fs.Var(&heads, "head", "A head printed by a previous verification; repeatable")
+	if err := fs.Parse(args[1:]); err != nil {
+		return fmt.Errorf("%w\n%s", err, auditUsage)
+	}
+
+	cfg.Database.AutoMigrate = false
+	db, err := database.NewDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	auditService := services.NewAuditService(repository.NewGormTransactionRepository(db.DB))
+	result, err := auditService.VerifyAuditChain(context.Background(), heads...)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Checked %d audit events\n", result.Checked)
+	if result.Checked > 0 {
+		head := audit.Head{Sequence: result.HeadSequence, Hash: result.HeadHash}
+		fmt.Printf("Head: sequence %d, hash %s\n", result.HeadSequence, result.HeadHash)
+		fmt.Printf("Record it outside the database and pass --head %s to later verifications\n", head)
+	}
+	if result.OK() {
+		fmt.Println("Audit chain is intact")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SEQUENCE\tID\tPROBLEM")
+	for _, problem := range result.Problems {
+		fmt.Fprintf(w, "%d\t%d\t%s\n", problem.Sequence, problem.ID, problem.Reason)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return fmt.Errorf("audit chain is broken: %d problems found", len(result.Problems))
+}
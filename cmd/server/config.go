@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"transaction-api/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+const configUsage = "usage: config print"
+
+// runConfig executes the config subcommand. loadErr is the error of loading
+// the configuration, returned after printing it.
+func runConfig(loader *config.Loader, loadErr error, args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return fmt.Errorf(configUsage)
+	}
+
+	if err := loader.Print(os.Stdout); err != nil {
+		return err
+	}
+	return loadErr
+}
+
+// fatalConfigError logs every configuration problem on its own line and
+// exits
+func fatalConfigError(err error) {
+	var validationErr *config.ValidationError
+	if errors.As(err, &validationErr) {
+		for _, problem := range validationErr.Problems {
+			logrus.Error(problem)
+		}
+		logrus.WithField("problems", len(validationErr.Problems)).Fatal("Invalid configuration")
+	}
+	logrus.WithError(err).Fatal("Failed to load configuration")
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,27 +12,81 @@ import (
 	"transaction-api/internal/config"
 	"transaction-api/internal/database"
 	"transaction-api/internal/handlers"
+	"transaction-api/internal/metrics"
 	"transaction-api/internal/middleware"
+	"transaction-api/internal/ratelimit"
+	"transaction-api/internal/redact"
+	"transaction-api/internal/reload"
+	"transaction-api/internal/repository"
 	"transaction-api/internal/services"
+	"transaction-api/internal/tlsserver"
+	"transaction-api/internal/tracing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
 func main() {
+	loader := config.NewLoader()
+	loader.RegisterFlags(flag.CommandLine)
+	skipMigrations := flag.Bool("skip-migrations", false, "Skip automatic database migration on startup")
+	flag.Parse()
+
 	// Load configuration
-	cfg, err := config.LoadConfig()
+	cfg, err := loader.Load()
+
+	// Print the configuration, even an invalid one, instead of serving
+	if flag.Arg(0) == "config" {
+		if err := runConfig(loader, err, flag.Args()[1:]); err != nil {
+			fatalConfigError(err)
+		}
+		return
+	}
 	if err != nil {
-		logrus.WithError(err).Fatal("Failed to load configuration")
+		fatalConfigError(err)
 	}
 
 	// Setup logger
 	middleware.SetupLogger(cfg.Log.Level)
+	redaction := redact.NewHook(cfg.Log.Redaction)
+	logrus.AddHook(redaction)
+
+	// Run the migrate subcommand instead of the server
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(cfg, flag.Args()[1:]); err != nil {
+			logrus.WithError(err).Fatal("Migration failed")
+		}
+		return
+	}
+	// Run the audit subcommand instead of the server
+	if flag.Arg(0) == "audit" {
+		if err := runAudit(cfg, flag.Args()[1:]); err != nil {
+			logrus.WithError(err).Fatal("Audit failed")
+		}
+		return
+	}
+	if *skipMigrations {
+		cfg.Database.AutoMigrate = false
+	}
+
 	logrus.Info("Starting Transaction API server...")
 
 	// Set Gin mode
 	gin.SetMode(cfg.Server.GinMode)
 
+	// Initialize tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to initialize tracing")
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logrus.WithError(err).Error("Failed to flush traces")
+		}
+	}()
+
 	// Initialize database
 	db, err := database.NewDatabase(cfg)
 	if err != nil {
@@ -43,14 +98,70 @@ func main() {
 		}
 	}()
 
+	if err := db.DB.Use(tracing.NewGormPlugin()); err != nil {
+		logrus.WithError(err).Fatal("Failed to register database tracing")
+	}
+
+	// Initialize metrics
+	var appMetrics *metrics.Metrics
+	if cfg.Metrics.Enabled {
+		appMetrics = metrics.New()
+		if err := db.DB.Use(metrics.NewGormPlugin(appMetrics)); err != nil {
+			logrus.WithError(err).Fatal("Failed to register database metrics")
+		}
+		sqlDB, err := db.DB.DB()
+		if err != nil {
+			logrus.WithError(err).Fatal("Failed to get database instance")
+		}
+		if err := appMetrics.RegisterDBStats(sqlDB, cfg.Database.Name); err != nil {
+			logrus.WithError(err).Fatal("Failed to register database pool metrics")
+		}
+	}
+
 	// Initialize services
-	transactionService := services.NewTransactionService(db.DB)
+	transactionRepository := repository.NewGormTransactionRepository(db.DB)
+	timeouts := services.Timeouts{
+		Read:      cfg.Database.ReadTimeout,
+		Write:     cfg.Database.WriteTimeout,
+		Analytics: cfg.Database.AnalyticsTimeout,
+	}
+
+	transactionService := services.NewTransactionServiceWithRepository(transactionRepository)
+	transactionService.SetFailureCodes(cfg.Transaction.FailureCodes)
+	transactionService.SetTimeouts(timeouts)
+	transactionService.SetMetrics(appMetrics)
+
+	auditService := services.NewAuditService(transactionRepository)
+	auditService.SetTimeouts(timeouts)
 
 	// Initialize handlers
 	transactionHandler := handlers.NewTransactionHandler(transactionService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	healthHandler := handlers.NewHealthHandler(db)
+
+	// Setup rate limiting
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		limiter = ratelimit.New(cfg.RateLimit, ratelimit.NewMemoryStore())
+	}
+
+	// Reload the reloadable settings on SIGHUP
+	corsPolicy := middleware.NewCORSPolicy(cfg.CORS)
+	reloader := reload.New(loader)
+	reloader.OnReload(func(cfg *config.Config) {
+		if err := middleware.SetLogLevel(cfg.Log.Level); err != nil {
+			logrus.WithError(err).Error("Failed to change the log level")
+		}
+		redaction.Update(cfg.Log.Redaction)
+		corsPolicy.Update(cfg.CORS)
+		if limiter != nil {
+			limiter.Update(cfg.RateLimit)
+		}
+	})
+	adminHandler := handlers.NewAdminHandler(reloader)
 
 	// Setup routes
-	router := setupRoutes(transactionHandler)
+	router := setupRoutes(cfg, appMetrics, limiter, corsPolicy, transactionHandler, auditHandler, healthHandler)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -58,24 +169,83 @@ func main() {
 		Handler: router,
 	}
 
+	// Serve HTTPS, optionally with client certificates, when configured
+	if cfg.TLS.Enabled() {
+		tlsServer, err := tlsserver.New(cfg.TLS)
+		if err != nil {
+			logrus.WithError(err).Fatal("Failed to configure TLS")
+		}
+		srv.TLSConfig = tlsServer.TLSConfig()
+
+		if cfg.TLS.ReloadInterval > 0 {
+			watchCtx, stopWatching := context.WithCancel(context.Background())
+			defer stopWatching()
+			go tlsServer.Watch(watchCtx, cfg.TLS.ReloadInterval)
+		}
+	}
+
 	// Start server in a goroutine
 	go func() {
-		logrus.WithField("port", cfg.Server.Port).Info("Server starting")
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logrus.WithFields(logrus.Fields{
+			"port":        cfg.Server.Port,
+			"tls":         cfg.TLS.Enabled(),
+			"client_auth": cfg.TLS.ClientAuth,
+		}).Info("Server starting")
+
+		var err error
+		if srv.TLSConfig != nil {
+			// The certificate comes from TLSConfig, so no files are passed
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			logrus.WithError(err).Fatal("Failed to start server")
 		}
 	}()
 
-	// Wait for interrupt signal to gracefully shutdown the server
+	// Admin endpoints have their own listener, by default reachable only from
+	// the host, so they are never exposed with the API
+	var adminSrv *http.Server
+	if cfg.Server.AdminAddr != "" {
+		adminSrv = &http.Server{
+			Addr:    cfg.Server.AdminAddr,
+			Handler: setupAdminRoutes(adminHandler),
+		}
+		go func() {
+			logrus.WithField("addr", cfg.Server.AdminAddr).Info("Admin server starting")
+			if err := adminSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logrus.WithError(err).Fatal("Failed to start admin server")
+			}
+		}()
+	}
+
+	// Wait for interrupt signal to gracefully shutdown the server, reloading
+	// the configuration on SIGHUP meanwhile
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := <-quit; sig == syscall.SIGHUP; sig = <-quit {
+		logrus.Info("Reloading configuration...")
+		reloader.Reload()
+	}
 	logrus.Info("Shutting down server...")
 
+	// Report not ready first so load balancers stop routing new requests
+	healthHandler.MarkShuttingDown()
+	if cfg.Server.ShutdownDelay > 0 {
+		logrus.WithField("delay", cfg.Server.ShutdownDelay.String()).Info("Waiting for load balancers to deregister")
+		time.Sleep(cfg.Server.ShutdownDelay)
+	}
+
 	// Graceful shutdown with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	if adminSrv != nil {
+		if err := adminSrv.Shutdown(ctx); err != nil {
+			logrus.WithError(err).Error("Admin server forced to shutdown")
+		}
+	}
 	if err := srv.Shutdown(ctx); err != nil {
 		logrus.WithError(err).Error("Server forced to shutdown")
 	} else {
@@ -83,33 +253,47 @@ func main() {
 	}
 }
 
-func setupRoutes(transactionHandler *handlers.TransactionHandler) *gin.Engine {
+func setupRoutes(cfg *config.Config, appMetrics *metrics.Metrics, limiter *ratelimit.Limiter, corsPolicy *middleware.CORSPolicy, transactionHandler *handlers.TransactionHandler, auditHandler *handlers.AuditHandler, healthHandler *handlers.HealthHandler) *gin.Engine {
 	router := gin.New()
 
+	// Client IPs come from X-Forwarded-For only behind trusted proxies, so
+	// clients cannot choose the IP they are rate limited and audited by
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		logrus.WithError(err).Fatal("Invalid trusted proxies")
+	}
+
 	// Add middleware
+	router.Use(middleware.Tracing())
+	router.Use(middleware.ClientCertificate())
+	router.Use(middleware.RequestID())
+	router.Use(middleware.AuditActor())
+	if appMetrics != nil {
+		router.Use(middleware.Metrics(appMetrics))
+	}
 	router.Use(middleware.LoggerMiddleware())
 	router.Use(middleware.ErrorHandler())
 	router.Use(gin.Recovery())
+	router.Use(middleware.ReadConsistency(cfg.Database.ReadYourWritesWindow))
+	router.Use(corsPolicy.Handler())
 
-	// Add CORS middleware
-	router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(http.StatusNoContent)
-			return
-		}
+	// Health check endpoint
+	router.GET("/health", healthHandler.HealthCheck)
+	router.GET("/health/live", healthHandler.Live)
+	router.GET("/health/ready", healthHandler.Ready)
 
-		c.Next()
-	})
+	// Prometheus metrics endpoint
+	if appMetrics != nil {
+		router.GET(cfg.Metrics.Path, gin.WrapH(appMetrics.Handler()))
+	}
 
-	// Health check endpoint
-	router.GET("/health", transactionHandler.HealthCheck)
+	// API routes are rate limited; health and metrics endpoints are not
+	api := router.Group("")
+	if limiter != nil {
+		api.Use(middleware.RateLimit(limiter, cfg.RateLimit.APIKeyHeader, cfg.RateLimit.APIKeys))
+	}
 
 	// API version 1 routes
-	v1 := router.Group("/api/v1")
+	v1 := api.Group("/api/v1")
 	{
 		// Transaction routes
 		transactions := v1.Group("/transactions")
@@ -125,16 +309,45 @@ func setupRoutes(transactionHandler *handlers.TransactionHandler) *gin.Engine {
 		dashboard := v1.Group("/dashboard")
 		{
 			dashboard.GET("/summary", transactionHandler.GetDashboardSummary)
+			dashboard.GET("/distribution", transactionHandler.GetAmountDistribution)
+			dashboard.GET("/top-users", transactionHandler.GetTopUsers)
+			dashboard.GET("/failures", transactionHandler.GetFailureBreakdown)
+		}
+
+		// User routes
+		users := v1.Group("/users")
+		{
+			users.GET("/:id/summary", transactionHandler.GetUserSummary)
+		}
+
+		// Audit routes
+		v1.GET("/audit", auditHandler.GetAuditEvents)
+
+		// Rate limit usage of the caller
+		if limiter != nil {
+			v1.GET("/rate-limit", handlers.NewRateLimitHandler(limiter).GetUsage)
 		}
 	}
 
 	// Legacy routes (without versioning) for backward compatibility
-	router.POST("/transactions", transactionHandler.CreateTransaction)
-	router.GET("/transactions", transactionHandler.GetTransactions)
-	router.GET("/transactions/:id", transactionHandler.GetTransactionByID)
-	router.PUT("/transactions/:id", transactionHandler.UpdateTransaction)
-	router.DELETE("/transactions/:id", transactionHandler.DeleteTransaction)
-	router.GET("/dashboard/summary", transactionHandler.GetDashboardSummary)
+	api.POST("/transactions", transactionHandler.CreateTransaction)
+	api.GET("/transactions", transactionHandler.GetTransactions)
+	api.GET("/transactions/:id", transactionHandler.GetTransactionByID)
+	api.PUT("/transactions/:id", transactionHandler.UpdateTransaction)
+	api.DELETE("/transactions/:id", transactionHandler.DeleteTransaction)
+	api.GET("/dashboard/summary", transactionHandler.GetDashboardSummary)
+
+	return router
+}
+
+// setupAdminRoutes returns the router of the admin listener
+func setupAdminRoutes(adminHandler *handlers.AdminHandler) *gin.Engine {
+	router := gin.New()
+	router.Use(middleware.RequestID())
+	router.Use(middleware.LoggerMiddleware())
+	router.Use(gin.Recovery())
+
+	router.GET("/admin/reload", adminHandler.GetReloadStatus)
 
 	return router
 }
\ No newline at end of file
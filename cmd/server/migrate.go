@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"transaction-api/internal/config"
+	"transaction-api/internal/database"
+)
+
+const migrateUsage = "usage: migrate up|down [steps]|status|to <version>"
+
+// runMigrate executes the migrate subcommand
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(migrateUsage)
+	}
+
+	// The subcommand controls migrations itself
+	cfg.Database.AutoMigrate = false
+	db, err := database.NewDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "up":
+		return db.Migrate()
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				return fmt.Errorf("invalid steps: %s", args[1])
+			}
+		}
+		return db.MigrateDown(steps)
+	case "to":
+		if len(args) < 2 {
+			return fmt.Errorf(migrateUsage)
+		}
+		version, err := strconv.ParseUint(args[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid version: %s", args[1])
+		}
+		return db.MigrateTo(uint(version))
+	case "status":
+		statuses, err := db.MigrationStatus()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, status := range statuses {
+			state, appliedAt := "pending", "-"
+			if status.Applied {
+				state, appliedAt = "applied", status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf(migrateUsage)
+	}
+}
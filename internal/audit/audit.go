@@ -0,0 +1,146 @@
+// Package audit builds and verifies the hash-chained audit events recorded
+// for every transaction mutation.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"transaction-api/internal/models"
+)
+
+// AnonymousActor is recorded for changes made by unauthenticated callers
+const AnonymousActor = "anonymous"
+
+// Actor identifies who made a change
+type Actor struct {
+	ID        string
+	IP        string
+	RequestID string
+}
+
+type contextKey struct{}
+
+// WithActor returns a copy of ctx attributing changes to actor
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, contextKey{}, actor)
+}
+
+// ActorFromContext returns the actor carried by ctx. Changes made outside a
+// request, such as from tests or scripts, have an empty actor.
+func ActorFromContext(ctx context.Context) Actor {
+	actor, _ := ctx.Value(contextKey{}).(Actor)
+	return actor
+}
+
+// NewTransactionEvent returns an unsealed event recording a change of a
+// transaction from before to after. before is nil for a create and after is
+// nil for a delete.
+func NewTransactionEvent(ctx context.Context, action models.AuditAction, id uint, before, after *models.Transaction) (*models.AuditEvent, error) {
+	actor := ActorFromContext(ctx)
+	event := &models.AuditEvent{
+		Action:     action,
+		EntityType: models.AuditEntityTransaction,
+		EntityID:   id,
+		Actor:      actor.ID,
+		IP:         actor.IP,
+		RequestID:  actor.RequestID,
+		// Stored timestamps keep millisecond precision on every database
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+
+	beforeFields, err := snapshot(before, &event.Before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := snapshot(after, &event.After)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]map[string]interface{})
+	for field, value := range afterFields {
+		if previous, ok := beforeFields[field]; !ok || !reflect.DeepEqual(previous, value) {
+			changes[field] = map[string]interface{}{"before": beforeFields[field], "after": value}
+		}
+	}
+	for field, value := range beforeFields {
+		if _, ok := afterFields[field]; !ok {
+			changes[field] = map[string]interface{}{"before": value, "after": nil}
+		}
+	}
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit changes: %w", err)
+	}
+	event.Changes = models.RawJSON(encoded)
+
+	return event, nil
+}
+
+// snapshot stores the JSON of entity in raw and returns its fields
+func snapshot(entity *models.Transaction, raw *models.RawJSON) (map[string]interface{}, error) {
+	if entity == nil {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(entity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit snapshot: %w", err)
+	}
+	*raw = models.RawJSON(encoded)
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode audit snapshot: %w", err)
+	}
+	// Timestamps maintained by the database are not changes of interest
+	delete(fields, "updated_at")
+	return fields, nil
+}
+
+// Seal chains event to prev, the latest stored event or nil for the first
+// one, and computes its hash
+func Seal(event *models.AuditEvent, prev *models.AuditEvent) {
+	event.Sequence = 1
+	event.PrevHash = ""
+	if prev != nil {
+		event.Sequence = prev.Sequence + 1
+		event.PrevHash = prev.Hash
+	}
+	event.Hash = Hash(event)
+}
+
+// Hash returns the hex SHA-256 of every event field except ID and Hash
+func Hash(event *models.AuditEvent) string {
+	fields := []string{
+		strconv.FormatUint(event.Sequence, 10),
+		event.PrevHash,
+		string(event.Action),
+		event.EntityType,
+		strconv.FormatUint(uint64(event.EntityID), 10),
+		event.Actor,
+		event.IP,
+		event.RequestID,
+		string(event.Before),
+		string(event.After),
+		string(event.Changes),
+		event.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+
+	// Length prefixes keep field boundaries unambiguous
+	var b strings.Builder
+	for _, field := range fields {
+		fmt.Fprintf(&b, "%d:%s;", len(field), field)
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,216 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"transaction-api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActorFromContext(t *testing.T) {
+	assert.Equal(t, Actor{}, ActorFromContext(context.Background()))
+
+	actor := Actor{ID: "alice", IP: "10.0.0.1", RequestID: "req-1"}
+	assert.Equal(t, actor, ActorFromContext(WithActor(context.Background(), actor)))
+}
+
+func TestNewTransactionEvent(t *testing.T) {
+	ctx := WithActor(context.Background(), Actor{ID: "alice", IP: "10.0.0.1", RequestID: "req-1"})
+	before := &models.Transaction{ID: 1, UserID: 1, Amount: 10, Status: models.StatusPending}
+	after := *before
+	after.Status = models.StatusFailed
+	after.FailureCode = "timeout"
+
+	event, err := NewTransactionEvent(ctx, models.AuditActionUpdate, 1, before, &after)
+	require.NoError(t, err)
+	assert.Equal(t, models.AuditActionUpdate, event.Action)
+	assert.Equal(t, models.AuditEntityTransaction, event.EntityType)
+	assert.Equal(t, uint(1), event.EntityID)
+	assert.Equal(t, "alice", event.Actor)
+	assert.Equal(t, "10.0.0.1", event.IP)
+	assert.Equal(t, "req-1", event.RequestID)
+	assert.False(t, event.CreatedAt.IsZero())
+	assert.JSONEq(t, `{
+		"status": {"before": "pending", "after": "failed"},
+		"failure_code": {"before": null, "after": "timeout"}
+	}`, string(event.Changes))
+
+	created, err := NewTransactionEvent(ctx, models.AuditActionCreate, 1, nil, before)
+	require.NoError(t, err)
+	assert.Empty(t, created.Before)
+	assert.NotEmpty(t, created.After)
+
+	deleted, err := NewTransactionEvent(ctx, models.AuditActionDelete, 1, before, nil)
+	require.NoError(t, err)
+	assert.NotEmpty(t, deleted.Before)
+	assert.Empty(t, deleted.After)
+}
+
+// chain returns n sealed events
+func chain(t *testing.T, n int) []models.AuditEvent {
+	events := make([]models.AuditEvent, n)
+	var prev *models.AuditEvent
+	for i := range events {
+		event, err := NewTransactionEvent(context.Background(), models.AuditActionCreate, uint(i+1), nil,
+			&models.Transaction{ID: uint(i + 1), UserID: 1, Amount: float64(i + 1)})
+		require.NoError(t, err)
+		Seal(event, prev)
+		event.ID = uint(i + 1)
+		events[i] = *event
+		prev = &events[i]
+	}
+	return events
+}
+
+// sliceSource serves events like a repository would
+func sliceSource(events []models.AuditEvent) Source {
+	return func(ctx context.Context, sequence uint64, limit int) ([]models.AuditEvent, error) {
+		var page []models.AuditEvent
+		for _, event := range events {
+			if event.Sequence > sequence && len(page) < limit {
+				page = append(page, event)
+			}
+		}
+		return page, nil
+	}
+}
+
+func TestSeal(t *testing.T) {
+	events := chain(t, 2)
+
+	assert.Equal(t, uint64(1), events[0].Sequence)
+	assert.Empty(t, events[0].PrevHash)
+	assert.Len(t, events[0].Hash, 64)
+	assert.Equal(t, uint64(2), events[1].Sequence)
+	assert.Equal(t, events[0].Hash, events[1].PrevHash)
+	assert.Equal(t, Hash(&events[1]), events[1].Hash)
+
+	// Every field is covered by the hash
+	altered := events[1]
+	altered.Actor = "mallory"
+	assert.NotEqual(t, events[1].Hash, Hash(&altered))
+	altered = events[1]
+	altered.CreatedAt = altered.CreatedAt.Add(1)
+	assert.NotEqual(t, events[1].Hash, Hash(&altered))
+}
+
+func TestParseHead(t *testing.T) {
+	head, err := ParseHead("42:abc123")
+	require.NoError(t, err)
+	assert.Equal(t, Head{Sequence: 42, Hash: "abc123"}, head)
+	assert.Equal(t, "42:abc123", head.String())
+
+	for _, invalid := range []string{"", "42", "abc:123", "0:abc", "42:"} {
+		_, err := ParseHead(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	t.Run("Intact", func(t *testing.T) {
+		events := chain(t, verifyBatchSize+3)
+
+		result, err := Verify(context.Background(), sliceSource(events))
+		require.NoError(t, err)
+		assert.True(t, result.OK())
+		assert.Equal(t, int64(len(events)), result.Checked)
+		assert.Equal(t, uint64(len(events)), result.HeadSequence)
+		assert.Equal(t, events[len(events)-1].Hash, result.HeadHash)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		result, err := Verify(context.Background(), sliceSource(nil))
+		require.NoError(t, err)
+		assert.True(t, result.OK())
+		assert.Zero(t, result.Checked)
+		assert.Empty(t, result.HeadHash)
+	})
+
+	t.Run("Altered", func(t *testing.T) {
+		events := chain(t, 3)
+		events[1].After = models.RawJSON(`{"amount": 1000}`)
+
+		result, err := Verify(context.Background(), sliceSource(events))
+		require.NoError(t, err)
+		assert.Equal(t, []Problem{{Sequence: 2, ID: 2, Reason: "hash does not match the event content"}}, result.Problems)
+	})
+
+	t.Run("Rehashed", func(t *testing.T) {
+		// Recomputing the hash of an altered event breaks the link to the next one
+		events := chain(t, 3)
+		events[1].Actor = "mallory"
+		events[1].Hash = Hash(&events[1])
+
+		result, err := Verify(context.Background(), sliceSource(events))
+		require.NoError(t, err)
+		assert.Equal(t, []Problem{{Sequence: 3, ID: 3, Reason: "previous hash does not match the preceding event"}}, result.Problems)
+	})
+
+	t.Run("RewrittenTail", func(t *testing.T) {
+		events := chain(t, 4)
+		head := Head{Sequence: 4, Hash: events[3].Hash}
+
+		// Rewriting an event and recomputing every later hash leaves a
+		// consistent chain that only the recorded head reveals
+		events[1].Actor = "mallory"
+		for i := 1; i < len(events); i++ {
+			events[i].PrevHash = events[i-1].Hash
+			events[i].Hash = Hash(&events[i])
+		}
+
+		result, err := Verify(context.Background(), sliceSource(events))
+		require.NoError(t, err)
+		assert.True(t, result.OK())
+
+		result, err = Verify(context.Background(), sliceSource(events), head)
+		require.NoError(t, err)
+		assert.Equal(t, []Problem{{Sequence: 4, ID: 4, Reason: "hash does not match the recorded head"}}, result.Problems)
+	})
+
+	t.Run("RecordedHeadMissing", func(t *testing.T) {
+		events := chain(t, 3)
+		head := Head{Sequence: 3, Hash: events[2].Hash}
+
+		result, err := Verify(context.Background(), sliceSource(events[:2]), head)
+		require.NoError(t, err)
+		assert.Equal(t, []Problem{{Sequence: 3, Reason: "the recorded head is missing"}}, result.Problems)
+
+		// A head older than the newest event still matches
+		result, err = Verify(context.Background(), sliceSource(events), Head{Sequence: 2, Hash: events[1].Hash})
+		require.NoError(t, err)
+		assert.True(t, result.OK())
+	})
+
+	t.Run("Removed", func(t *testing.T) {
+		events := chain(t, 4)
+		events = append(events[:1], events[3:]...)
+
+		result, err := Verify(context.Background(), sliceSource(events))
+		require.NoError(t, err)
+		assert.Equal(t, []Problem{
+			{Sequence: 4, ID: 4, Reason: "events 2 to 3 are missing"},
+			{Sequence: 4, ID: 4, Reason: "previous hash does not match the preceding event"},
+		}, result.Problems)
+	})
+
+	t.Run("RemovedFirst", func(t *testing.T) {
+		events := chain(t, 2)[1:]
+
+		result, err := Verify(context.Background(), sliceSource(events))
+		require.NoError(t, err)
+		assert.Len(t, result.Problems, 2)
+		assert.Equal(t, "events 1 to 1 are missing", result.Problems[0].Reason)
+	})
+
+	t.Run("SourceError", func(t *testing.T) {
+		errSource := errors.New("connection lost")
+		_, err := Verify(context.Background(), func(context.Context, uint64, int) ([]models.AuditEvent, error) {
+			return nil, errSource
+		})
+		assert.ErrorIs(t, err, errSource)
+	})
+}
@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"transaction-api/internal/models"
+)
+
+// verifyBatchSize is how many events Verify reads at a time
+const verifyBatchSize = 500
+
+// Source returns up to limit events with a sequence greater than sequence,
+// in ascending sequence order
+type Source func(ctx context.Context, sequence uint64, limit int) ([]models.AuditEvent, error)
+
+// Problem describes a break in the audit chain
+type Problem struct {
+	Sequence uint64 `json:"sequence"`
+	ID       uint   `json:"id,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+// Head is a previously recorded head of the chain. Anyone able to write the
+// audit events can rewrite them and recompute every later hash, which only a
+// head recorded elsewhere reveals.
+type Head struct {
+	Sequence uint64
+	Hash     string
+}
+
+// ParseHead parses a head written as "SEQUENCE:HASH"
+func ParseHead(s string) (Head, error) {
+	sequence, hash, ok := strings.Cut(s, ":")
+	parsed, err := strconv.ParseUint(sequence, 10, 64)
+	if !ok || err != nil || parsed == 0 || hash == "" {
+		return Head{}, errors.New("head must be SEQUENCE:HASH, as printed by a previous verification")
+	}
+	return Head{Sequence: parsed, Hash: hash}, nil
+}
+
+func (h Head) String() string {
+	return strconv.FormatUint(h.Sequence, 10) + ":" + h.Hash
+}
+
+// VerifyResult reports the outcome of verifying the audit chain. Removing
+// the newest events or rewriting the chain cannot be detected from the chain
+// alone, so HeadSequence and HeadHash should be recorded and passed to later
+// verifications.
+type VerifyResult struct {
+	Checked      int64     `json:"checked"`
+	HeadSequence uint64    `json:"head_sequence"`
+	HeadHash     string    `json:"head_hash"`
+	Problems     []Problem `json:"problems"`
+}
+
+// OK reports whether the chain is intact
+func (r *VerifyResult) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Verify walks the whole audit chain and reports altered events, whose hash
+// no longer matches their content, and removed events, which leave a gap in
+// the sequence and a previous hash that does not match. The events at the
+// sequences of heads must still have the recorded hashes.
+func Verify(ctx context.Context, source Source, heads ...Head) (*VerifyResult, error) {
+	result := &VerifyResult{Problems: []Problem{}}
+
+	recorded := make(map[uint64]string, len(heads))
+	for _, head := range heads {
+		recorded[head.Sequence] = head.Hash
+	}
+
+	var prev *models.AuditEvent
+	var sequence uint64
+	for {
+		events, err := source(ctx, sequence, verifyBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read audit events: %w", err)
+		}
+
+		for i := range events {
+			event := &events[i]
+			result.check(prev, event)
+			if hash, ok := recorded[event.Sequence]; ok {
+				if event.Hash != hash {
+					result.Problems = append(result.Problems, Problem{
+						Sequence: event.Sequence,
+						ID:       event.ID,
+						Reason:   "hash does not match the recorded head",
+					})
+				}
+				delete(recorded, event.Sequence)
+			}
+			prev = event
+			sequence = event.Sequence
+			result.Checked++
+		}
+
+		if len(events) < verifyBatchSize {
+			break
+		}
+	}
+
+	if prev != nil {
+		result.HeadSequence = prev.Sequence
+		result.HeadHash = prev.Hash
+	}
+	for _, head := range heads {
+		if _, missing := recorded[head.Sequence]; missing {
+			result.Problems = append(result.Problems, Problem{
+				Sequence: head.Sequence,
+				Reason:   "the recorded head is missing",
+			})
+		}
+	}
+	return result, nil
+}
+
+// check verifies event against the event before it
+func (r *VerifyResult) check(prev, event *models.AuditEvent) {
+	expectedSequence, expectedPrevHash := uint64(1), ""
+	if prev != nil {
+		expectedSequence, expectedPrevHash = prev.Sequence+1, prev.Hash
+	}
+
+	switch {
+	case event.Sequence < expectedSequence:
+		r.Problems = append(r.Problems, Problem{
+			Sequence: event.Sequence,
+			ID:       event.ID,
+			Reason:   fmt.Sprintf("sequence is out of order, expected %d", expectedSequence),
+		})
+	case event.Sequence > expectedSequence:
+		r.Problems = append(r.Problems, Problem{
+			Sequence: event.Sequence,
+			ID:       event.ID,
+			Reason:   fmt.Sprintf("events %d to %d are missing", expectedSequence, event.Sequence-1),
+		})
+	}
+	if event.PrevHash != expectedPrevHash {
+		r.Problems = append(r.Problems, Problem{
+			Sequence: event.Sequence,
+			ID:       event.ID,
+			Reason:   "previous hash does not match the preceding event",
+		})
+	}
+	if event.Hash != Hash(event) {
+		r.Problems = append(r.Problems, Problem{
+			Sequence: event.Sequence,
+			ID:       event.ID,
+			Reason:   "hash does not match the event content",
+		})
+	}
+}
@@ -1,70 +1,170 @@
+// Package config loads the application configuration from defaults, a YAML
+// or TOML file, environment variables and command-line flags, in increasing
+// order of precedence, and validates it.
 package config
 
-import (
-	"os"
-	"strconv"
-
-	"github.com/joho/godotenv"
-	"github.com/sirupsen/logrus"
-)
+import "time"
 
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
-	Log      LogConfig
+	Database    DatabaseConfig
+	Server      ServerConfig
+	Log         LogConfig
+	Transaction TransactionConfig
+	Metrics     MetricsConfig
+	Tracing     TracingConfig
+	RateLimit   RateLimitConfig
+	CORS        CORSConfig
+	TLS         TLSConfig
 }
 
 type DatabaseConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	Name     string
+	Driver      string
+	DSN         string
+	Host        string
+	Port        int
+	User        string
+	Password    string
+	Name        string
+	AutoMigrate bool
+
+	// Read replicas (full DSNs for the same driver) and how long a client's
+	// reads stay on the primary after it writes
+	ReplicaDSNs          []string
+	ReadYourWritesWindow time.Duration
+
+	// Connection pool
+	MaxIdleConns    int
+	MaxOpenConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// Startup connection retry with exponential backoff; zero disables retry
+	ConnectMaxWait       time.Duration
+	ConnectRetryInterval time.Duration
+
+	// Per-query timeouts by operation kind; zero disables the timeout
+	ReadTimeout      time.Duration
+	WriteTimeout     time.Duration
+	AnalyticsTimeout time.Duration
 }
 
 type ServerConfig struct {
 	Port    string
 	GinMode string
+	// ShutdownDelay is how long the server reports not ready before draining
+	ShutdownDelay time.Duration
+	// TrustedProxies are the IPs and CIDRs of proxies whose X-Forwarded-For
+	// headers are believed; empty uses the address of the connection
+	TrustedProxies []string
+	// AdminAddr is the address of the separate admin listener; empty
+	// disables the admin endpoints
+	AdminAddr string
 }
 
 type LogConfig struct {
-	Level string
+	Level     string
+	Redaction RedactionConfig
 }
 
-func LoadConfig() (*Config, error) {
-	// Load .env file if it exists
-	if err := godotenv.Load(); err != nil {
-		logrus.Warn("No .env file found, using environment variables")
-	}
-
-	dbPort, err := strconv.Atoi(getEnv("DB_PORT", "3306"))
-	if err != nil {
-		return nil, err
-	}
-
-	config := &Config{
-		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     dbPort,
-			User:     getEnv("DB_USER", "root"),
-			Password: getEnv("DB_PASSWORD", "password"),
-			Name:     getEnv("DB_NAME", "transaction_db"),
-		},
-		Server: ServerConfig{
-			Port:    getEnv("SERVER_PORT", "8080"),
-			GinMode: getEnv("GIN_MODE", "debug"),
-		},
-		Log: LogConfig{
-			Level: getEnv("LOG_LEVEL", "info"),
-		},
-	}
-
-	return config, nil
+// RedactionConfig masks sensitive data in log entries
+type RedactionConfig struct {
+	// Mode is denylist, which masks Fields and Patterns, or allowlist, which
+	// also masks every field not in AllowedFields
+	Mode string
+	// Fields are the names of fields whose values are masked, matched
+	// ignoring case, "_" and "-"
+	Fields []string
+	// Patterns are masked in every logged string: pan, email, phone
+	Patterns      []string
+	AllowedFields []string
+}
+
+type TransactionConfig struct {
+	FailureCodes []string
+}
+
+type MetricsConfig struct {
+	Enabled bool
+	Path    string
+}
+
+type TracingConfig struct {
+	// Exporter is one of: none, otlp, stdout, file
+	Exporter    string
+	ServiceName string
+	SampleRatio float64
+
+	// OTLP over HTTP; an empty endpoint uses the OTEL_EXPORTER_OTLP_* variables
+	OTLPEndpoint string
+	OTLPInsecure bool
+
+	// File receives spans as JSON lines when Exporter is file
+	File string
+}
+
+type RateLimitConfig struct {
+	Enabled bool
+	// APIKeyHeader identifies API clients; clients without a key are limited
+	// by user, then by IP
+	APIKeyHeader string
+	// APIKeys are the issued keys; other keys are limited like requests
+	// without one
+	APIKeys []string
+	// Default applies to routes without their own policy; a zero Limit
+	// leaves them unlimited
+	Default RateLimitPolicy
+	// Routes maps "METHOD /path" route patterns, without the /api/v1
+	// prefix, to their policies
+	Routes map[string]RateLimitPolicy
+}
+
+// RateLimitPolicy is a token bucket refilled with Limit tokens per Period
+// and holding at most Burst tokens, plus an optional daily quota
+type RateLimitPolicy struct {
+	Limit  int
+	Period time.Duration
+	// Burst defaults to Limit
+	Burst int
+	// DailyQuota caps the requests per UTC day; zero is unlimited
+	DailyQuota int64
+}
+
+type CORSConfig struct {
+	// AllowedOrigins are exact origins such as "https://app.example.com",
+	// wildcard subdomains such as "https://*.example.com", or "*" for any
+	// origin. Empty rejects every cross-origin request.
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache preflight responses
+	MaxAge time.Duration
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
\ No newline at end of file
+// TLSConfig enables HTTPS when CertFile is set
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile is a PEM bundle of CAs that client certificates must
+	// chain to
+	ClientCAFile string
+	// ClientAuth is one of: none, optional, require
+	ClientAuth string
+	// MinVersion is one of: 1.2, 1.3
+	MinVersion string
+	// ReloadInterval is how often the files are checked for changes; zero
+	// disables reloading
+	ReloadInterval time.Duration
+}
+
+// Enabled reports whether the server should serve HTTPS
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// LoadConfig loads the configuration from the config file named by
+// CONFIG_FILE and the environment, without flags
+func LoadConfig() (*Config, error) {
+	return NewLoader().Load()
+}
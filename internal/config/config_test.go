@@ -0,0 +1,44 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRateLimitPolicy(t *testing.T) {
+	policy, err := parseRateLimitPolicy("60/1m burst=10 quota=10000")
+	require.NoError(t, err)
+	assert.Equal(t, RateLimitPolicy{Limit: 60, Period: time.Minute, Burst: 10, DailyQuota: 10000}, policy)
+
+	policy, err = parseRateLimitPolicy("5/1s")
+	require.NoError(t, err)
+	assert.Equal(t, RateLimitPolicy{Limit: 5, Period: time.Second, Burst: 5}, policy)
+
+	for _, spec := range []string{"", "off"} {
+		policy, err = parseRateLimitPolicy(spec)
+		require.NoError(t, err, spec)
+		assert.Zero(t, policy, spec)
+	}
+
+	for _, spec := range []string{"60", "0/1m", "x/1m", "60/0s", "60/minute", "60/1m burst=0", "60/1m quota=-1", "60/1m colour=red"} {
+		_, err := parseRateLimitPolicy(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestParseRateLimitRoutes(t *testing.T) {
+	routes, err := parseRateLimitRoutes("post /transactions=60/1m burst=10; GET /dashboard/summary=off;")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]RateLimitPolicy{
+		"POST /transactions":     {Limit: 60, Period: time.Minute, Burst: 10},
+		"GET /dashboard/summary": {},
+	}, routes)
+
+	for _, value := range []string{"POST /transactions", "/transactions=60/1m", "POST transactions=60/1m", "POST /transactions=60"} {
+		_, err := parseRateLimitRoutes(value)
+		assert.Error(t, err, value)
+	}
+}
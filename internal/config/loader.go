@@ -0,0 +1,375 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// Source is the layer a configuration value came from
+type Source string
+
+// Layers in increasing order of precedence
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "file"
+	SourceDotenv  Source = ".env"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+)
+
+// secretFileSuffix names the environment variable holding the path of a
+// file with the value, such as DB_PASSWORD_FILE
+const secretFileSuffix = "_FILE"
+
+// ValidationError lists every problem found in the configuration
+type ValidationError struct {
+	Problems []string
+	// Settings are the names of the invalid settings, without their values;
+	// problems with the config file itself are reported as CONFIG_FILE
+	Settings []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// value is the effective raw value of a setting
+type value struct {
+	raw    string
+	source Source
+}
+
+// Loader loads the configuration from its layers: defaults, the config file,
+// the .env file, the environment and flags, each overriding the ones before
+type Loader struct {
+	file   string
+	dotenv string
+	flags  map[string]string
+
+	// loaded are the values of the last Load or Reload, for Print and to
+	// find the settings a Reload changes
+	loaded map[string]value
+}
+
+// NewLoader returns a loader that reads the config file named by
+// CONFIG_FILE, if any
+func NewLoader() *Loader {
+	return &Loader{dotenv: ".env", flags: make(map[string]string)}
+}
+
+// RegisterFlags adds -config and a flag overriding every setting to fs,
+// such as -db-port for DB_PORT
+func (l *Loader) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&l.file, "config", "", "Path of a YAML or TOML config file (overrides CONFIG_FILE)")
+	for _, s := range settings(&Config{}) {
+		env := s.env
+		fs.Func(s.flagName(), "Overrides "+env, func(value string) error {
+			l.flags[env] = value
+			return nil
+		})
+	}
+}
+
+// Load resolves every setting from its layers and validates the result.
+// All problems are reported at once in a *ValidationError.
+func (l *Loader) Load() (*Config, error) {
+	cfg, values, err := l.load()
+	// Print shows the values even when they are invalid
+	l.loaded = values
+	return cfg, err
+}
+
+// Change is a setting whose value differs from the previous load
+type Change struct {
+	Setting string
+	// Reloadable settings take effect at runtime; others need a restart
+	Reloadable bool
+}
+
+// Reload loads the configuration again and reports the settings whose values
+// changed since the previous load. Settings that are not reloadable keep
+// their previous values in the returned configuration. On error nothing
+// changes.
+func (l *Loader) Reload() (*Config, []Change, error) {
+	cfg, values, err := l.load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var changes []Change
+	for _, s := range settings(cfg) {
+		previous, ok := l.loaded[s.env]
+		if !ok || previous.raw == values[s.env].raw {
+			continue
+		}
+		changes = append(changes, Change{Setting: s.env, Reloadable: s.reloadable})
+		if !s.reloadable {
+			// The previous value was valid when it was loaded
+			_ = s.parse(previous.raw)
+			values[s.env] = previous
+		}
+	}
+	l.loaded = values
+	return cfg, changes, nil
+}
+
+// load resolves and validates every setting, returning the raw values too
+func (l *Loader) load() (*Config, map[string]value, error) {
+	cfg := &Config{}
+	list := settings(cfg)
+	dotenv := l.readDotenv(list)
+	problems := make(map[string][]string)
+	var general []string
+
+	values := make(map[string]value, len(list))
+	for _, s := range list {
+		values[s.env] = value{raw: s.def, source: SourceDefault}
+	}
+
+	file := l.file
+	if file == "" {
+		file = os.Getenv("CONFIG_FILE")
+	}
+	if file == "" {
+		file = dotenv["CONFIG_FILE"]
+	}
+	if file != "" {
+		fileValues, err := readFile(file, list)
+		general = append(general, err...)
+		for env, raw := range fileValues {
+			values[env] = value{raw: raw, source: SourceFile}
+		}
+	}
+
+	for _, s := range list {
+		raw, ok, err := lookupEnv(s.env, os.Getenv)
+		source := SourceEnv
+		if err == nil && !ok {
+			raw, ok, err = lookupEnv(s.env, func(name string) string { return dotenv[name] })
+			source = SourceDotenv
+		}
+		if err != nil {
+			problems[s.env] = append(problems[s.env], err.Error())
+		} else if ok {
+			values[s.env] = value{raw: raw, source: source}
+		}
+	}
+
+	for env, raw := range l.flags {
+		values[env] = value{raw: raw, source: SourceFlag}
+	}
+
+	if port := values["DB_PORT"]; port.raw == "" {
+		values["DB_PORT"] = value{raw: defaultDBPort(values["DB_DRIVER"].raw), source: port.source}
+	}
+
+	for _, s := range list {
+		if err := s.parse(values[s.env].raw); err != nil {
+			problems[s.env] = append(problems[s.env], err.Error())
+		}
+	}
+
+	// Values that failed to parse are not validated further
+	for env, messages := range cfg.validate() {
+		if len(problems[env]) == 0 {
+			problems[env] = messages
+		}
+	}
+
+	all := general
+	var invalid []string
+	if len(general) > 0 {
+		invalid = append(invalid, "CONFIG_FILE")
+	}
+	for _, s := range list {
+		for _, message := range problems[s.env] {
+			all = append(all, fmt.Sprintf("%s (from %s): %s", s.env, values[s.env].source, message))
+		}
+		if len(problems[s.env]) > 0 {
+			invalid = append(invalid, s.env)
+		}
+	}
+	if len(all) > 0 {
+		return nil, values, &ValidationError{Problems: all, Settings: invalid}
+	}
+	return cfg, values, nil
+}
+
+// defaultDBPort returns the standard port of a database driver
+func defaultDBPort(driver string) string {
+	if driver == "postgres" {
+		return "5432"
+	}
+	return "3306"
+}
+
+// readDotenv reads the .env file on every load, so reloads see its changes.
+// Its variables that are not settings, such as the standard OTEL_ ones, are
+// exported to the environment on the first load for the libraries reading
+// them; variables already in the environment are kept.
+func (l *Loader) readDotenv(list []setting) map[string]string {
+	first := l.loaded == nil
+	dotenv, err := godotenv.Read(l.dotenv)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if first {
+			logrus.Warn("No .env file found, using environment variables")
+		}
+		return nil
+	case err != nil:
+		logrus.WithError(err).Warn("Failed to read .env file, using environment variables")
+		return nil
+	}
+	if !first {
+		return dotenv
+	}
+
+	known := map[string]bool{"CONFIG_FILE": true}
+	for _, s := range list {
+		known[s.env] = true
+		known[s.env+secretFileSuffix] = true
+	}
+	for name, raw := range dotenv {
+		if _, set := os.LookupEnv(name); !set && !known[name] {
+			os.Setenv(name, raw)
+		}
+	}
+	return dotenv
+}
+
+// lookupEnv returns the value of a variable from getenv, or the content of
+// the file named by its _FILE variable. Setting both is an error.
+func lookupEnv(name string, getenv func(string) string) (string, bool, error) {
+	value := getenv(name)
+	file := getenv(name + secretFileSuffix)
+
+	switch {
+	case value != "" && file != "":
+		return "", false, fmt.Errorf("both %s and %s%s are set", name, name, secretFileSuffix)
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to read %s%s: %w", name, secretFileSuffix, err)
+		}
+		// Files usually end with a newline that is not part of the secret
+		return strings.TrimRight(string(data), "\r\n"), true, nil
+	case value != "":
+		return value, true, nil
+	}
+	return "", false, nil
+}
+
+// readFile reads a YAML or TOML config file, chosen by extension, and returns
+// the raw value of every setting it contains, by env name
+func readFile(path string, list []setting) (map[string]string, []string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("failed to read config file: %v", err)}
+	}
+
+	document := make(map[string]interface{})
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &document)
+	case ".toml":
+		err = toml.Unmarshal(data, &document)
+	default:
+		return nil, []string{fmt.Sprintf("config file %s must be .yaml, .yml or .toml", path)}
+	}
+	if err != nil {
+		return nil, []string{fmt.Sprintf("failed to parse config file %s: %v", path, err)}
+	}
+
+	envByKey := make(map[string]string, len(list))
+	for _, s := range list {
+		envByKey[s.key] = s.env
+	}
+
+	values := make(map[string]string)
+	var problems []string
+	var walk func(prefix string, node map[string]interface{})
+	walk = func(prefix string, node map[string]interface{}) {
+		for name, item := range node {
+			key := prefix + name
+			if env, ok := envByKey[key]; ok {
+				raw, err := fileValue(item)
+				if err != nil {
+					problems = append(problems, fmt.Sprintf("%s (from file): %v", key, err))
+					continue
+				}
+				values[env] = raw
+				continue
+			}
+			if section, ok := item.(map[string]interface{}); ok {
+				walk(key+".", section)
+				continue
+			}
+			problems = append(problems, fmt.Sprintf("%s (from file): unknown setting", key))
+		}
+	}
+	walk("", document)
+
+	sort.Strings(problems)
+	return values, problems
+}
+
+// fileValue converts a value decoded from a config file to the raw form used
+// by the environment: arrays become comma separated lists and tables become
+// "key=value" entries separated by semicolons
+func fileValue(item interface{}) (string, error) {
+	switch v := item.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, element := range v {
+			raw, err := fileValue(element)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, raw)
+		}
+		return strings.Join(items, ","), nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		entries := make([]string, 0, len(v))
+		for _, key := range keys {
+			raw, err := fileValue(v[key])
+			if err != nil {
+				return "", err
+			}
+			entries = append(entries, key+"="+raw)
+		}
+		return strings.Join(entries, "; "), nil
+	}
+	return "", errors.New("unsupported value type")
+}
@@ -0,0 +1,345 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// load loads the configuration with args as command-line flags
+func load(t *testing.T, args ...string) (*Config, *Loader, error) {
+	loader := NewLoader()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	loader.RegisterFlags(fs)
+	require.NoError(t, fs.Parse(args))
+
+	cfg, err := loader.Load()
+	return cfg, loader, err
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, _, err := load(t)
+	require.NoError(t, err)
+
+	assert.Equal(t, "mysql", cfg.Database.Driver)
+	assert.Equal(t, 3306, cfg.Database.Port)
+	assert.Equal(t, 100, cfg.Database.MaxOpenConns)
+	assert.Equal(t, time.Hour, cfg.Database.ConnMaxLifetime)
+	assert.Equal(t, "8080", cfg.Server.Port)
+	assert.Equal(t, "127.0.0.1:8081", cfg.Server.AdminAddr)
+	assert.Equal(t, "info", cfg.Log.Level)
+	assert.NotEmpty(t, cfg.Transaction.FailureCodes)
+	assert.Equal(t, RateLimitPolicy{Limit: 600, Period: time.Minute, Burst: 600}, cfg.RateLimit.Default)
+	assert.Len(t, cfg.RateLimit.Routes, 2)
+	assert.Empty(t, cfg.CORS.AllowedOrigins)
+	assert.Equal(t, []string{"GET", "POST", "PUT", "PATCH", "DELETE"}, cfg.CORS.AllowedMethods)
+	assert.False(t, cfg.TLS.Enabled())
+	assert.NotContains(t, cfg.Log.Redaction.AllowedFields, "details")
+
+	// The database port follows the driver
+	t.Setenv("DB_DRIVER", "postgres")
+	cfg, _, err = load(t)
+	require.NoError(t, err)
+	assert.Equal(t, 5432, cfg.Database.Port)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeConfigFile(t, "config.yaml", `
+database:
+  host: db.internal
+  port: 3307
+  max_open_conns: 50
+log:
+  level: warn
+server:
+  port: "9000"
+`)
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("DB_PORT", "3308")
+	t.Setenv("LOG_LEVEL", "debug")
+
+	cfg, loader, err := load(t, "-log-level=error")
+	require.NoError(t, err)
+
+	assert.Equal(t, "root", cfg.Database.User, "default")
+	assert.Equal(t, "db.internal", cfg.Database.Host, "file")
+	assert.Equal(t, 50, cfg.Database.MaxOpenConns, "file")
+	assert.Equal(t, "9000", cfg.Server.Port, "file")
+	assert.Equal(t, 3308, cfg.Database.Port, "env overrides file")
+	assert.Equal(t, "error", cfg.Log.Level, "flag overrides env and file")
+
+	assert.Equal(t, SourceDefault, loader.loaded["DB_USER"].source)
+	assert.Equal(t, SourceFile, loader.loaded["DB_HOST"].source)
+	assert.Equal(t, SourceEnv, loader.loaded["DB_PORT"].source)
+	assert.Equal(t, SourceFlag, loader.loaded["LOG_LEVEL"].source)
+
+	// -config overrides CONFIG_FILE
+	other := writeConfigFile(t, "other.yaml", "database:\n  host: other.internal\n")
+	cfg, _, err = load(t, "-config", other)
+	require.NoError(t, err)
+	assert.Equal(t, "other.internal", cfg.Database.Host)
+}
+
+func TestLoadFileFormats(t *testing.T) {
+	yamlFile := writeConfigFile(t, "config.yml", `
+transaction:
+  failure_codes: [timeout, declined]
+tracing:
+  sample_ratio: 0.25
+rate_limit:
+  routes:
+    POST /transactions: 5/1s burst=1
+    GET /dashboard/summary: "off"
+cors:
+  allowed_origins:
+    - https://app.example.com
+    - https://*.example.com
+  allow_credentials: true
+`)
+	tomlFile := writeConfigFile(t, "config.toml", `
+[transaction]
+failure_codes = ["timeout", "declined"]
+
+[tracing]
+sample_ratio = 0.25
+
+[rate_limit.routes]
+"POST /transactions" = "5/1s burst=1"
+"GET /dashboard/summary" = "off"
+
+[cors]
+allowed_origins = ["https://app.example.com", "https://*.example.com"]
+allow_credentials = true
+`)
+
+	for _, file := range []string{yamlFile, tomlFile} {
+		cfg, _, err := load(t, "-config", file)
+		require.NoError(t, err, file)
+
+		assert.Equal(t, []string{"timeout", "declined"}, cfg.Transaction.FailureCodes, file)
+		assert.Equal(t, 0.25, cfg.Tracing.SampleRatio, file)
+		assert.Equal(t, map[string]RateLimitPolicy{
+			"POST /transactions":     {Limit: 5, Period: time.Second, Burst: 1},
+			"GET /dashboard/summary": {},
+		}, cfg.RateLimit.Routes, file)
+		assert.Equal(t, []string{"https://app.example.com", "https://*.example.com"}, cfg.CORS.AllowedOrigins, file)
+		assert.True(t, cfg.CORS.AllowCredentials, file)
+	}
+}
+
+func TestLoadSecretFiles(t *testing.T) {
+	t.Setenv("DB_PASSWORD_FILE", writeConfigFile(t, "password", "s3cret\n"))
+
+	cfg, _, err := load(t)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", cfg.Database.Password)
+
+	// A value and a file for the same setting are ambiguous
+	t.Setenv("DB_PASSWORD", "other")
+	_, _, err = load(t)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "both DB_PASSWORD and DB_PASSWORD_FILE are set")
+
+	t.Setenv("DB_PASSWORD", "")
+	t.Setenv("DB_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+	_, _, err = load(t)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read DB_PASSWORD_FILE")
+}
+
+func TestLoadDotenv(t *testing.T) {
+	path := writeConfigFile(t, ".env", "LOG_LEVEL=debug\nDB_NAME=from_dotenv\nTEST_DOTENV_OTHER=exported\n")
+	t.Setenv("DB_NAME", "from_env")
+	for _, name := range []string{"LOG_LEVEL", "TEST_DOTENV_OTHER"} {
+		t.Setenv(name, "")
+		require.NoError(t, os.Unsetenv(name))
+	}
+
+	loader := NewLoader()
+	loader.dotenv = path
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+
+	// .env is a layer below the environment
+	assert.Equal(t, "debug", cfg.Log.Level)
+	assert.Equal(t, "from_env", cfg.Database.Name)
+
+	// Only variables that are not settings are exported, for libraries
+	assert.Equal(t, "exported", os.Getenv("TEST_DOTENV_OTHER"))
+	_, exported := os.LookupEnv("LOG_LEVEL")
+	assert.False(t, exported)
+
+	// Reloads read the file again
+	require.NoError(t, os.WriteFile(path, []byte("LOG_LEVEL=warn\nDB_NAME=from_dotenv\n"), 0o600))
+	cfg, changes, err := loader.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, "warn", cfg.Log.Level)
+	assert.Equal(t, []Change{{Setting: "LOG_LEVEL", Reloadable: true}}, changes)
+}
+
+func TestLoadReportsAllErrors(t *testing.T) {
+	file := writeConfigFile(t, "config.yaml", `
+database:
+  prot: 3306
+loging:
+  level: info
+`)
+	t.Setenv("LOG_LEVEL", "verbose")
+	t.Setenv("LOG_REDACT_PATTERNS", "pan,iban")
+	t.Setenv("DB_MAX_OPEN_CONNS", "many")
+	t.Setenv("TRACING_SAMPLE_RATIO", "2")
+	t.Setenv("SERVER_TRUSTED_PROXIES", "10.0.0.0/8,proxy.internal")
+	t.Setenv("SERVER_ADMIN_ADDR", "localhost")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example.com,app.example.com")
+	t.Setenv("TLS_CLIENT_AUTH", "require")
+
+	_, _, err := load(t, "-config", file, "-db-driver=oracle")
+	require.Error(t, err)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{
+		"database.prot (from file): unknown setting",
+		"loging.level (from file): unknown setting",
+		`DB_DRIVER (from flag): "oracle" is not one of: mysql, postgres, sqlite`,
+		`DB_MAX_OPEN_CONNS (from env): "many" is not an integer`,
+		`SERVER_TRUSTED_PROXIES (from env): "proxy.internal" is not an IP address or CIDR`,
+		`SERVER_ADMIN_ADDR (from env): "localhost" is not a host and port`,
+		`LOG_LEVEL (from env): "verbose" is not one of: panic, fatal, error, warn, info, debug, trace`,
+		`LOG_REDACT_PATTERNS (from env): "iban" is not one of: pan, email, phone`,
+		"TRACING_SAMPLE_RATIO (from env): 2 is not between 0 and 1",
+		`CORS_ALLOWED_ORIGINS (from env): "app.example.com" must start with http:// or https://`,
+		"TLS_CLIENT_CA_FILE (from default): is required when TLS_CLIENT_AUTH is require",
+	}, validationErr.Problems)
+	assert.Equal(t, []string{
+		"CONFIG_FILE", "DB_DRIVER", "DB_MAX_OPEN_CONNS", "SERVER_TRUSTED_PROXIES", "SERVER_ADMIN_ADDR",
+		"LOG_LEVEL", "LOG_REDACT_PATTERNS", "TRACING_SAMPLE_RATIO", "CORS_ALLOWED_ORIGINS", "TLS_CLIENT_CA_FILE",
+	}, validationErr.Settings)
+}
+
+func TestLoadRejectsAnyOriginWithCredentials(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example.com,*")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+
+	_, _, err := load(t)
+	require.Error(t, err)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{
+		`CORS_ALLOWED_ORIGINS (from env): "*" cannot be combined with CORS_ALLOW_CREDENTIALS`,
+	}, validationErr.Problems)
+
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "false")
+	_, _, err = load(t)
+	assert.NoError(t, err)
+}
+
+func TestLoadInvalidFile(t *testing.T) {
+	for name, content := range map[string]string{
+		"config.yaml": "database: [",
+		"config.json": "{}",
+	} {
+		_, _, err := load(t, "-config", writeConfigFile(t, name, content))
+		assert.Error(t, err, name)
+	}
+
+	_, _, err := load(t, "-config", filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "failed to read config file")
+}
+
+func TestReload(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+log:
+  level: info
+server:
+  port: "8080"
+`)
+	cfg, loader, err := load(t, "-config", path)
+	require.NoError(t, err)
+	assert.Equal(t, "info", cfg.Log.Level)
+
+	// Nothing changed
+	cfg, changes, err := loader.Reload()
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+	assert.Equal(t, "info", cfg.Log.Level)
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+log:
+  level: debug
+server:
+  port: "9090"
+cors:
+  allowed_origins: [https://app.example.com]
+`), 0o600))
+
+	cfg, changes, err = loader.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Setting: "SERVER_PORT"},
+		{Setting: "LOG_LEVEL", Reloadable: true},
+		{Setting: "CORS_ALLOWED_ORIGINS", Reloadable: true},
+	}, changes)
+	assert.Equal(t, "debug", cfg.Log.Level)
+	assert.Equal(t, []string{"https://app.example.com"}, cfg.CORS.AllowedOrigins)
+	// The port cannot change at runtime and keeps its previous value
+	assert.Equal(t, "8080", cfg.Server.Port)
+
+	// A rejected setting is reported again until the process restarts
+	_, changes, err = loader.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []Change{{Setting: "SERVER_PORT"}}, changes)
+
+	// An invalid file is rejected as a whole
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: loud\n"), 0o600))
+	_, _, err = loader.Reload()
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: debug\nserver:\n  port: \"8080\"\ncors:\n  allowed_origins: [https://app.example.com]\n"), 0o600))
+	_, changes, err = loader.Reload()
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestPrint(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "hunter2")
+	t.Setenv("DB_DSN", "root:hunter2@tcp(db)/transactions")
+
+	_, loader, err := load(t, "-log-level=debug")
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, loader.Print(&out))
+	printed := out.String()
+
+	assert.NotContains(t, printed, "hunter2")
+	assert.Contains(t, printed, `password: "[REDACTED]" # env`)
+	assert.Contains(t, printed, `dsn: "[REDACTED]" # env`)
+	assert.Contains(t, printed, `replica_dsns: "" # default`)
+	assert.Contains(t, printed, `level: "debug" # flag`)
+
+	// The printed configuration is a valid config file
+	t.Setenv("DB_PASSWORD", "")
+	t.Setenv("DB_DSN", "")
+	cfg, _, err := load(t, "-config", writeConfigFile(t, "printed.yaml", printed))
+	require.NoError(t, err)
+	assert.Equal(t, "debug", cfg.Log.Level)
+	assert.Equal(t, redacted, cfg.Database.Password)
+
+	assert.Error(t, NewLoader().Print(&out))
+}
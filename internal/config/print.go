@@ -0,0 +1,50 @@
+package config
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces secret values when the configuration is printed
+const redacted = "[REDACTED]"
+
+// Print writes the configuration resolved by the last Load as a YAML config
+// file, with the layer each value came from as a comment. Secrets are
+// redacted.
+func (l *Loader) Print(w io.Writer) error {
+	if l.loaded == nil {
+		return errors.New("configuration has not been loaded")
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	sections := make(map[string]*yaml.Node)
+	for _, s := range settings(&Config{}) {
+		sectionName, name, _ := strings.Cut(s.key, ".")
+		section, ok := sections[sectionName]
+		if !ok {
+			section = &yaml.Node{Kind: yaml.MappingNode}
+			sections[sectionName] = section
+			root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: sectionName}, section)
+		}
+
+		v := l.loaded[s.env]
+		raw := v.raw
+		if s.secret && raw != "" {
+			raw = redacted
+		}
+		section.Content = append(section.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: name},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Style: yaml.DoubleQuotedStyle, Value: raw, LineComment: string(v.source)},
+		)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
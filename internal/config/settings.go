@@ -0,0 +1,276 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"transaction-api/internal/models"
+)
+
+// setting is one configuration value. It has the same name in every layer:
+// DB_PORT in the environment is database.port in config files and
+// --db-port on the command line.
+type setting struct {
+	env string
+	key string
+	def string
+	// secret values are redacted when the configuration is printed
+	secret bool
+	// reloadable settings take effect on SIGHUP without a restart
+	reloadable bool
+	// parse stores the value in the Config the setting was created for
+	parse func(value string) error
+}
+
+// flagName returns the command-line flag of the setting
+func (s setting) flagName() string {
+	return strings.ReplaceAll(strings.ToLower(s.env), "_", "-")
+}
+
+// settings returns every setting, storing into cfg, in the order they are
+// documented and printed
+func settings(cfg *Config) []setting {
+	db := &cfg.Database
+	return []setting{
+		stringSetting("DB_DRIVER", "database.driver", "mysql", &db.Driver),
+		secretSetting("DB_DSN", "database.dsn", "", &db.DSN),
+		stringSetting("DB_HOST", "database.host", "localhost", &db.Host),
+		// An empty port is replaced by the driver's default port
+		intSetting("DB_PORT", "database.port", "", &db.Port),
+		stringSetting("DB_USER", "database.user", "root", &db.User),
+		secretSetting("DB_PASSWORD", "database.password", "password", &db.Password),
+		stringSetting("DB_NAME", "database.name", "transaction_db", &db.Name),
+		boolSetting("DB_AUTO_MIGRATE", "database.auto_migrate", "true", &db.AutoMigrate),
+		secretListSetting("DB_REPLICA_DSNS", "database.replica_dsns", "", &db.ReplicaDSNs),
+		durationSetting("DB_READ_YOUR_WRITES_WINDOW", "database.read_your_writes_window", "5s", &db.ReadYourWritesWindow),
+		intSetting("DB_MAX_IDLE_CONNS", "database.max_idle_conns", "10", &db.MaxIdleConns),
+		intSetting("DB_MAX_OPEN_CONNS", "database.max_open_conns", "100", &db.MaxOpenConns),
+		durationSetting("DB_CONN_MAX_LIFETIME", "database.conn_max_lifetime", "1h", &db.ConnMaxLifetime),
+		durationSetting("DB_CONN_MAX_IDLE_TIME", "database.conn_max_idle_time", "0s", &db.ConnMaxIdleTime),
+		durationSetting("DB_CONNECT_MAX_WAIT", "database.connect_max_wait", "30s", &db.ConnectMaxWait),
+		durationSetting("DB_CONNECT_RETRY_INTERVAL", "database.connect_retry_interval", "500ms", &db.ConnectRetryInterval),
+		durationSetting("DB_READ_TIMEOUT", "database.read_timeout", "5s", &db.ReadTimeout),
+		durationSetting("DB_WRITE_TIMEOUT", "database.write_timeout", "5s", &db.WriteTimeout),
+		durationSetting("DB_ANALYTICS_TIMEOUT", "database.analytics_timeout", "30s", &db.AnalyticsTimeout),
+
+		stringSetting("SERVER_PORT", "server.port", "8080", &cfg.Server.Port),
+		stringSetting("GIN_MODE", "server.gin_mode", "debug", &cfg.Server.GinMode),
+		durationSetting("SERVER_SHUTDOWN_DELAY", "server.shutdown_delay", "0s", &cfg.Server.ShutdownDelay),
+		listSetting("SERVER_TRUSTED_PROXIES", "server.trusted_proxies", "", &cfg.Server.TrustedProxies),
+		stringSetting("SERVER_ADMIN_ADDR", "server.admin_addr", "127.0.0.1:8081", &cfg.Server.AdminAddr),
+
+		reloadable(stringSetting("LOG_LEVEL", "log.level", "info", &cfg.Log.Level)),
+		reloadable(stringSetting("LOG_REDACT_MODE", "log.redact_mode", "denylist", &cfg.Log.Redaction.Mode)),
+		reloadable(listSetting("LOG_REDACT_FIELDS", "log.redact_fields",
+			"password,secret,token,authorization,api_key,card_number,pan,cvv,email,phone,account_number", &cfg.Log.Redaction.Fields)),
+		reloadable(listSetting("LOG_REDACT_PATTERNS", "log.redact_patterns", "pan,email,phone", &cfg.Log.Redaction.Patterns)),
+		// details is left out: validation details quote request payload values
+		reloadable(listSetting("LOG_REDACT_ALLOWED_FIELDS", "log.redact_allowed_fields",
+			"amount,applied,attempt,caller,cert_file,client_auth,client_ip,db_driver,delay,direction,error,exporter,"+
+				"failure_code,latency,max_wait,method,name,new_status,path,port,problems,rejected,replicas,request_id,retry_in,"+
+				"route,sample_ratio,setting,span_id,status_code,tls,trace_id,transaction_id,user_id,version",
+			&cfg.Log.Redaction.AllowedFields)),
+
+		listSetting("FAILURE_CODES", "transaction.failure_codes", strings.Join(models.DefaultFailureCodes, ","), &cfg.Transaction.FailureCodes),
+
+		boolSetting("METRICS_ENABLED", "metrics.enabled", "true", &cfg.Metrics.Enabled),
+		stringSetting("METRICS_PATH", "metrics.path", "/metrics", &cfg.Metrics.Path),
+
+		stringSetting("TRACING_EXPORTER", "tracing.exporter", "none", &cfg.Tracing.Exporter),
+		stringSetting("TRACING_SERVICE_NAME", "tracing.service_name", "transaction-api", &cfg.Tracing.ServiceName),
+		floatSetting("TRACING_SAMPLE_RATIO", "tracing.sample_ratio", "1", &cfg.Tracing.SampleRatio),
+		stringSetting("TRACING_OTLP_ENDPOINT", "tracing.otlp_endpoint", "", &cfg.Tracing.OTLPEndpoint),
+		boolSetting("TRACING_OTLP_INSECURE", "tracing.otlp_insecure", "false", &cfg.Tracing.OTLPInsecure),
+		stringSetting("TRACING_FILE", "tracing.file", "traces.json", &cfg.Tracing.File),
+
+		boolSetting("RATE_LIMIT_ENABLED", "rate_limit.enabled", "true", &cfg.RateLimit.Enabled),
+		stringSetting("RATE_LIMIT_API_KEY_HEADER", "rate_limit.api_key_header", "X-API-Key", &cfg.RateLimit.APIKeyHeader),
+		secretListSetting("RATE_LIMIT_API_KEYS", "rate_limit.api_keys", "", &cfg.RateLimit.APIKeys),
+		{
+			env:        "RATE_LIMIT_DEFAULT",
+			key:        "rate_limit.default",
+			def:        "600/1m",
+			reloadable: true,
+			parse: func(value string) (err error) {
+				cfg.RateLimit.Default, err = parseRateLimitPolicy(value)
+				return err
+			},
+		},
+		{
+			env:        "RATE_LIMIT_ROUTES",
+			key:        "rate_limit.routes",
+			def:        "POST /transactions=60/1m burst=10; GET /dashboard/summary=10/1m burst=2",
+			reloadable: true,
+			parse: func(value string) (err error) {
+				cfg.RateLimit.Routes, err = parseRateLimitRoutes(value)
+				return err
+			},
+		},
+
+		reloadable(listSetting("CORS_ALLOWED_ORIGINS", "cors.allowed_origins", "", &cfg.CORS.AllowedOrigins)),
+		reloadable(listSetting("CORS_ALLOWED_METHODS", "cors.allowed_methods", "GET,POST,PUT,PATCH,DELETE", &cfg.CORS.AllowedMethods)),
+		reloadable(listSetting("CORS_ALLOWED_HEADERS", "cors.allowed_headers",
+			"Content-Type,Authorization,X-API-Key,X-Read-Consistency,X-Read-Consistency-Token,X-Request-ID", &cfg.CORS.AllowedHeaders)),
+		reloadable(listSetting("CORS_EXPOSED_HEADERS", "cors.exposed_headers",
+			"X-Request-ID,X-Read-Consistency-Token,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,RateLimit-Policy,Retry-After", &cfg.CORS.ExposedHeaders)),
+		reloadable(boolSetting("CORS_ALLOW_CREDENTIALS", "cors.allow_credentials", "false", &cfg.CORS.AllowCredentials)),
+		reloadable(durationSetting("CORS_MAX_AGE", "cors.max_age", "10m", &cfg.CORS.MaxAge)),
+
+		stringSetting("TLS_CERT_FILE", "tls.cert_file", "", &cfg.TLS.CertFile),
+		stringSetting("TLS_KEY_FILE", "tls.key_file", "", &cfg.TLS.KeyFile),
+		stringSetting("TLS_CLIENT_CA_FILE", "tls.client_ca_file", "", &cfg.TLS.ClientCAFile),
+		stringSetting("TLS_CLIENT_AUTH", "tls.client_auth", "none", &cfg.TLS.ClientAuth),
+		stringSetting("TLS_MIN_VERSION", "tls.min_version", "1.2", &cfg.TLS.MinVersion),
+		durationSetting("TLS_RELOAD_INTERVAL", "tls.reload_interval", "30s", &cfg.TLS.ReloadInterval),
+	}
+}
+
+// reloadable marks a setting that takes effect on SIGHUP
+func reloadable(s setting) setting {
+	s.reloadable = true
+	return s
+}
+
+func stringSetting(env, key, def string, target *string) setting {
+	return setting{env: env, key: key, def: def, parse: func(value string) error {
+		*target = value
+		return nil
+	}}
+}
+
+func secretSetting(env, key, def string, target *string) setting {
+	s := stringSetting(env, key, def, target)
+	s.secret = true
+	return s
+}
+
+func intSetting(env, key, def string, target *int) setting {
+	return setting{env: env, key: key, def: def, parse: func(value string) (err error) {
+		if value == "" {
+			return nil
+		}
+		if *target, err = strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		return nil
+	}}
+}
+
+func floatSetting(env, key, def string, target *float64) setting {
+	return setting{env: env, key: key, def: def, parse: func(value string) (err error) {
+		if *target, err = strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		return nil
+	}}
+}
+
+func boolSetting(env, key, def string, target *bool) setting {
+	return setting{env: env, key: key, def: def, parse: func(value string) (err error) {
+		if *target, err = strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		return nil
+	}}
+}
+
+func durationSetting(env, key, def string, target *time.Duration) setting {
+	return setting{env: env, key: key, def: def, parse: func(value string) (err error) {
+		if *target, err = time.ParseDuration(value); err != nil {
+			return fmt.Errorf("%q is not a duration such as 30s or 5m", value)
+		}
+		return nil
+	}}
+}
+
+// listSetting parses comma separated values; files may also use arrays
+func listSetting(env, key, def string, target *[]string) setting {
+	return setting{env: env, key: key, def: def, parse: func(value string) error {
+		*target = splitList(value)
+		return nil
+	}}
+}
+
+func secretListSetting(env, key, def string, target *[]string) setting {
+	s := listSetting(env, key, def, target)
+	s.secret = true
+	return s
+}
+
+// splitList splits a comma separated list, dropping empty items
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// parseRateLimitPolicy parses "LIMIT/PERIOD [burst=N] [quota=N]", such as
+// "60/1m burst=10 quota=10000". "off" disables the policy.
+func parseRateLimitPolicy(spec string) (RateLimitPolicy, error) {
+	var policy RateLimitPolicy
+
+	fields := strings.Fields(spec)
+	if len(fields) == 0 || (len(fields) == 1 && fields[0] == "off") {
+		return policy, nil
+	}
+
+	limit, period, ok := strings.Cut(fields[0], "/")
+	if !ok {
+		return policy, fmt.Errorf("invalid rate %q, expected LIMIT/PERIOD", fields[0])
+	}
+	var err error
+	if policy.Limit, err = strconv.Atoi(limit); err != nil || policy.Limit <= 0 {
+		return policy, fmt.Errorf("invalid limit %q", limit)
+	}
+	if policy.Period, err = time.ParseDuration(period); err != nil || policy.Period <= 0 {
+		return policy, fmt.Errorf("invalid period %q", period)
+	}
+	policy.Burst = policy.Limit
+
+	for _, field := range fields[1:] {
+		name, value, _ := strings.Cut(field, "=")
+		switch name {
+		case "burst":
+			if policy.Burst, err = strconv.Atoi(value); err != nil || policy.Burst <= 0 {
+				return policy, fmt.Errorf("invalid burst %q", value)
+			}
+		case "quota":
+			if policy.DailyQuota, err = strconv.ParseInt(value, 10, 64); err != nil || policy.DailyQuota < 0 {
+				return policy, fmt.Errorf("invalid quota %q", value)
+			}
+		default:
+			return policy, fmt.Errorf("unknown option %q", field)
+		}
+	}
+	return policy, nil
+}
+
+// parseRateLimitRoutes parses semicolon separated "METHOD /path=POLICY"
+// entries
+func parseRateLimitRoutes(value string) (map[string]RateLimitPolicy, error) {
+	routes := make(map[string]RateLimitPolicy)
+	for _, entry := range strings.Split(value, ";") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+
+		route, spec, ok := strings.Cut(entry, "=")
+		method, path, hasPath := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok || !hasPath || !strings.HasPrefix(strings.TrimSpace(path), "/") {
+			return nil, fmt.Errorf("invalid entry %q, expected METHOD /path=POLICY", entry)
+		}
+
+		policy, err := parseRateLimitPolicy(spec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", route, err)
+		}
+		routes[strings.ToUpper(method)+" "+strings.TrimSpace(path)] = policy
+	}
+	return routes, nil
+}
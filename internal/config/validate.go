@@ -0,0 +1,164 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// problems collects validation messages by setting
+type problems map[string][]string
+
+func (p problems) add(env, format string, args ...interface{}) {
+	p[env] = append(p[env], fmt.Sprintf(format, args...))
+}
+
+// oneOf checks that value is one of allowed
+func (p problems) oneOf(env, value string, allowed ...string) {
+	for _, candidate := range allowed {
+		if value == candidate {
+			return
+		}
+	}
+	p.add(env, "%q is not one of: %s", value, strings.Join(allowed, ", "))
+}
+
+// nonNegative checks durations and counts that cannot be negative
+func (p problems) nonNegative(env string, value time.Duration) {
+	if value < 0 {
+		p.add(env, "must not be negative")
+	}
+}
+
+// validate checks the parsed configuration and returns its problems by
+// setting
+func (c *Config) validate() problems {
+	p := make(problems)
+
+	db := c.Database
+	p.oneOf("DB_DRIVER", db.Driver, "mysql", "postgres", "sqlite")
+	if db.Port < 1 || db.Port > 65535 {
+		p.add("DB_PORT", "%d is not a port between 1 and 65535", db.Port)
+	}
+	if db.DSN == "" && db.Name == "" {
+		p.add("DB_NAME", "is required unless DB_DSN is set")
+	}
+	if db.MaxIdleConns < 0 {
+		p.add("DB_MAX_IDLE_CONNS", "must not be negative")
+	}
+	if db.MaxOpenConns < 0 {
+		p.add("DB_MAX_OPEN_CONNS", "must not be negative")
+	}
+	if db.MaxOpenConns > 0 && db.MaxIdleConns > db.MaxOpenConns {
+		p.add("DB_MAX_IDLE_CONNS", "%d exceeds DB_MAX_OPEN_CONNS %d", db.MaxIdleConns, db.MaxOpenConns)
+	}
+	p.nonNegative("DB_READ_YOUR_WRITES_WINDOW", db.ReadYourWritesWindow)
+	p.nonNegative("DB_CONN_MAX_LIFETIME", db.ConnMaxLifetime)
+	p.nonNegative("DB_CONN_MAX_IDLE_TIME", db.ConnMaxIdleTime)
+	p.nonNegative("DB_CONNECT_MAX_WAIT", db.ConnectMaxWait)
+	if db.ConnectMaxWait > 0 && db.ConnectRetryInterval <= 0 {
+		p.add("DB_CONNECT_RETRY_INTERVAL", "must be positive when DB_CONNECT_MAX_WAIT is set")
+	}
+	p.nonNegative("DB_READ_TIMEOUT", db.ReadTimeout)
+	p.nonNegative("DB_WRITE_TIMEOUT", db.WriteTimeout)
+	p.nonNegative("DB_ANALYTICS_TIMEOUT", db.AnalyticsTimeout)
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		p.add("SERVER_PORT", "%q is not a port between 1 and 65535", c.Server.Port)
+	}
+	p.oneOf("GIN_MODE", c.Server.GinMode, "debug", "release", "test")
+	p.nonNegative("SERVER_SHUTDOWN_DELAY", c.Server.ShutdownDelay)
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, err := netip.ParsePrefix(proxy); err != nil {
+			if _, err := netip.ParseAddr(proxy); err != nil {
+				p.add("SERVER_TRUSTED_PROXIES", "%q is not an IP address or CIDR", proxy)
+			}
+		}
+	}
+	if c.Server.AdminAddr != "" {
+		_, port, err := net.SplitHostPort(c.Server.AdminAddr)
+		if n, convErr := strconv.Atoi(port); err != nil || convErr != nil || n < 1 || n > 65535 {
+			p.add("SERVER_ADMIN_ADDR", "%q is not a host and port", c.Server.AdminAddr)
+		}
+	}
+
+	if _, err := logrus.ParseLevel(c.Log.Level); err != nil {
+		p.add("LOG_LEVEL", "%q is not one of: panic, fatal, error, warn, info, debug, trace", c.Log.Level)
+	}
+
+	p.oneOf("LOG_REDACT_MODE", c.Log.Redaction.Mode, "denylist", "allowlist")
+	for _, pattern := range c.Log.Redaction.Patterns {
+		p.oneOf("LOG_REDACT_PATTERNS", pattern, "pan", "email", "phone")
+	}
+
+	if len(c.Transaction.FailureCodes) == 0 {
+		p.add("FAILURE_CODES", "at least one failure code is required")
+	}
+
+	if !strings.HasPrefix(c.Metrics.Path, "/") {
+		p.add("METRICS_PATH", "%q must start with /", c.Metrics.Path)
+	}
+
+	p.oneOf("TRACING_EXPORTER", c.Tracing.Exporter, "none", "otlp", "stdout", "file")
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		p.add("TRACING_SAMPLE_RATIO", "%v is not between 0 and 1", c.Tracing.SampleRatio)
+	}
+	if c.Tracing.Exporter == "file" && c.Tracing.File == "" {
+		p.add("TRACING_FILE", "is required when TRACING_EXPORTER is file")
+	}
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		if err := validateOrigin(origin); err != nil {
+			p.add("CORS_ALLOWED_ORIGINS", "%q %v", origin, err)
+		}
+		// Any site could make credentialed requests on behalf of the user
+		if origin == "*" && c.CORS.AllowCredentials {
+			p.add("CORS_ALLOWED_ORIGINS", "\"*\" cannot be combined with CORS_ALLOW_CREDENTIALS")
+		}
+	}
+	p.nonNegative("CORS_MAX_AGE", c.CORS.MaxAge)
+
+	tls := c.TLS
+	if (tls.CertFile == "") != (tls.KeyFile == "") {
+		p.add("TLS_KEY_FILE", "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	p.oneOf("TLS_CLIENT_AUTH", tls.ClientAuth, "none", "optional", "require")
+	if tls.ClientAuth != "none" && tls.ClientCAFile == "" {
+		p.add("TLS_CLIENT_CA_FILE", "is required when TLS_CLIENT_AUTH is %s", tls.ClientAuth)
+	}
+	if tls.ClientCAFile != "" && !tls.Enabled() {
+		p.add("TLS_CLIENT_CA_FILE", "requires TLS_CERT_FILE")
+	}
+	p.oneOf("TLS_MIN_VERSION", tls.MinVersion, "1.2", "1.3")
+	p.nonNegative("TLS_RELOAD_INTERVAL", tls.ReloadInterval)
+
+	return p
+}
+
+// validateOrigin checks a CORS origin: "*", or a scheme and host with an
+// optional "*." wildcard subdomain and port
+func validateOrigin(origin string) error {
+	if origin == "*" {
+		return nil
+	}
+
+	parsed, err := url.Parse(strings.Replace(origin, "://*.", "://wildcard.", 1))
+	switch {
+	case err != nil:
+		return err
+	case parsed.Scheme != "http" && parsed.Scheme != "https":
+		return errors.New("must start with http:// or https://")
+	case parsed.Host == "" || strings.Contains(parsed.Host, "*"):
+		return errors.New("must have a host, optionally starting with *.")
+	case strings.TrimSuffix(parsed.Path, "/") != "" || parsed.RawQuery != "" || parsed.User != nil:
+		return errors.New("must not have a path, query or credentials")
+	}
+	return nil
+}
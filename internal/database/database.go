@@ -1,16 +1,20 @@
 package database
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"transaction-api/internal/config"
-	"transaction-api/internal/models"
 
+	"github.com/glebarez/sqlite"
 	"github.com/sirupsen/logrus"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
 )
 
 type Database struct {
@@ -18,14 +22,6 @@ type Database struct {
 }
 
 func NewDatabase(cfg *config.Config) (*Database, error) {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		cfg.Database.User,
-		cfg.Database.Password,
-		cfg.Database.Host,
-		cfg.Database.Port,
-		cfg.Database.Name,
-	)
-
 	// Configure GORM logger
 	var gormLogger logger.Interface
 	if cfg.Server.GinMode == "release" {
@@ -34,14 +30,16 @@ func NewDatabase(cfg *config.Config) (*Database, error) {
 		gormLogger = logger.Default.LogMode(logger.Info)
 	}
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+	db, err := openWithRetry(cfg.Database, &gorm.Config{
 		Logger: gormLogger,
 		NowFunc: func() time.Time {
 			return time.Now().UTC()
 		},
+		// Report driver errors such as duplicate keys as gorm errors
+		TranslateError: true,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, err
 	}
 
 	// Configure connection pool
@@ -50,12 +48,33 @@ func NewDatabase(cfg *config.Config) (*Database, error) {
 		return nil, fmt.Errorf("failed to get database instance: %w", err)
 	}
 
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	sqlDB.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.Database.ConnMaxIdleTime)
+
+	// Every connection to an in-memory SQLite database opens a new database
+	if cfg.Database.Driver == "sqlite" && strings.Contains(cfg.Database.DSN, ":memory:") {
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetMaxIdleConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+		sqlDB.SetConnMaxIdleTime(0)
+	}
+
+	if len(cfg.Database.ReplicaDSNs) > 0 {
+		if err := registerReplicas(db, cfg.Database); err != nil {
+			return nil, err
+		}
+		logrus.WithField("replicas", len(cfg.Database.ReplicaDSNs)).Info("Read replicas configured")
+	}
 
 	database := &Database{DB: db}
 
+	if !cfg.Database.AutoMigrate {
+		logrus.Info("Database connected, automatic migration skipped")
+		return database, nil
+	}
+
 	if err := database.Migrate(); err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
@@ -64,15 +83,116 @@ func NewDatabase(cfg *config.Config) (*Database, error) {
 	return database, nil
 }
 
-func (d *Database) Migrate() error {
-	if err := d.DB.AutoMigrate(&models.Transaction{}); err != nil {
-		return fmt.Errorf("failed to migrate Transaction model: %w", err)
+// maxConnectRetryInterval caps the exponential backoff between connection attempts
+const maxConnectRetryInterval = 10 * time.Second
+
+// openWithRetry opens the database, retrying with exponential backoff until
+// ConnectMaxWait has elapsed. This covers databases that start after the API,
+// as commonly happens with docker-compose.
+func openWithRetry(cfg config.DatabaseConfig, gormConfig *gorm.Config) (*gorm.DB, error) {
+	start := time.Now()
+	interval := cfg.ConnectRetryInterval
+	if interval <= 0 {
+		interval = maxConnectRetryInterval
 	}
 
-	logrus.Info("Database migration completed successfully")
+	for attempt := 1; ; attempt++ {
+		dialector, err := newDialector(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		db, err := gorm.Open(dialector, gormConfig)
+		if err == nil {
+			return db, nil
+		}
+
+		if time.Since(start)+interval > cfg.ConnectMaxWait {
+			return nil, fmt.Errorf("failed to connect to database after %d attempts: %w", attempt, err)
+		}
+
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"attempt":   attempt,
+			"retry_in":  interval.String(),
+			"max_wait":  cfg.ConnectMaxWait.String(),
+			"db_driver": cfg.Driver,
+		}).Warn("Database connection failed, retrying")
+
+		time.Sleep(interval)
+		interval *= 2
+		if interval > maxConnectRetryInterval {
+			interval = maxConnectRetryInterval
+		}
+	}
+}
+
+// registerReplicas routes queries to the read replicas and writes to the primary
+func registerReplicas(db *gorm.DB, cfg config.DatabaseConfig) error {
+	replicas := make([]gorm.Dialector, 0, len(cfg.ReplicaDSNs))
+	for _, dsn := range cfg.ReplicaDSNs {
+		replicaCfg := cfg
+		replicaCfg.DSN = dsn
+		dialector, err := newDialector(replicaCfg)
+		if err != nil {
+			return err
+		}
+		replicas = append(replicas, dialector)
+	}
+
+	resolver := dbresolver.Register(dbresolver.Config{
+		Replicas: replicas,
+		Policy:   dbresolver.RandomPolicy{},
+	}).
+		SetMaxIdleConns(cfg.MaxIdleConns).
+		SetMaxOpenConns(cfg.MaxOpenConns).
+		SetConnMaxLifetime(cfg.ConnMaxLifetime).
+		SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	if err := db.Use(resolver); err != nil {
+		return fmt.Errorf("failed to register read replicas: %w", err)
+	}
 	return nil
 }
 
+// newDialector returns the GORM dialector for the configured driver.
+// DSN overrides the connection string built from the individual settings.
+func newDialector(cfg config.DatabaseConfig) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case "mysql":
+		dsn := cfg.DSN
+		if dsn == "" {
+			dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+				cfg.User,
+				cfg.Password,
+				cfg.Host,
+				cfg.Port,
+				cfg.Name,
+			)
+		}
+		return mysql.Open(dsn), nil
+	case "postgres":
+		dsn := cfg.DSN
+		if dsn == "" {
+			dsn = fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable TimeZone=UTC",
+				cfg.Host,
+				cfg.Port,
+				cfg.User,
+				cfg.Password,
+				cfg.Name,
+			)
+		}
+		return postgres.Open(dsn), nil
+	case "sqlite":
+		dsn := cfg.DSN
+		if dsn == "" {
+			dsn = cfg.Name + ".db?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+		}
+		return sqlite.Open(dsn), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", cfg.Driver)
+	}
+}
+
 func (d *Database) Close() error {
 	sqlDB, err := d.DB.DB()
 	if err != nil {
@@ -83,9 +203,48 @@ func (d *Database) Close() error {
 
 // Health check for database connection
 func (d *Database) Ping() error {
+	return d.PingContext(context.Background())
+}
+
+// PingContext checks the database connection, giving up when ctx is done
+func (d *Database) PingContext(ctx context.Context) error {
 	sqlDB, err := d.DB.DB()
 	if err != nil {
 		return err
 	}
-	return sqlDB.Ping()
-}
\ No newline at end of file
+	return sqlDB.PingContext(ctx)
+}
+
+// PoolStats represents the connection pool statistics
+type PoolStats struct {
+	MaxOpenConnections int    `json:"max_open_connections"`
+	OpenConnections    int    `json:"open_connections"`
+	InUse              int    `json:"in_use"`
+	Idle               int    `json:"idle"`
+	WaitCount          int64  `json:"wait_count"`
+	WaitDuration       string `json:"wait_duration"`
+	MaxIdleClosed      int64  `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64  `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64  `json:"max_lifetime_closed"`
+}
+
+// Stats returns the connection pool statistics
+func (d *Database) Stats() (PoolStats, error) {
+	sqlDB, err := d.DB.DB()
+	if err != nil {
+		return PoolStats{}, err
+	}
+
+	stats := sqlDB.Stats()
+	return PoolStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDuration:       stats.WaitDuration.String(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}, nil
+}
@@ -0,0 +1,171 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"transaction-api/internal/config"
+	"transaction-api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestDatabase(t *testing.T, autoMigrate bool) *Database {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			Driver:      "sqlite",
+			DSN:         ":memory:",
+			AutoMigrate: autoMigrate,
+		},
+		Server: config.ServerConfig{GinMode: "release"},
+	}
+
+	db, err := NewDatabase(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestNewDatabaseSQLite(t *testing.T) {
+	db := newTestDatabase(t, true)
+
+	assert.NoError(t, db.Ping())
+	assert.True(t, db.DB.Migrator().HasTable(&models.Transaction{}))
+
+	// The migrated schema matches the model
+	transaction := models.Transaction{UserID: 1, Amount: 10, Status: models.StatusFailed, FailureCode: "timeout"}
+	assert.NoError(t, db.DB.Create(&transaction).Error)
+	assert.NotZero(t, transaction.ID)
+}
+
+func TestNewDatabaseSkipMigrations(t *testing.T) {
+	db := newTestDatabase(t, false)
+
+	assert.False(t, db.DB.Migrator().HasTable(&models.Transaction{}))
+}
+
+func TestMigrations(t *testing.T) {
+	db := newTestDatabase(t, false)
+
+	statuses, err := db.MigrationStatus()
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+	assert.False(t, statuses[0].Applied)
+
+	// Up applies everything and is idempotent
+	require.NoError(t, db.Migrate())
+	require.NoError(t, db.Migrate())
+
+	statuses, err = db.MigrationStatus()
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.True(t, status.Applied)
+		assert.NotNil(t, status.AppliedAt)
+	}
+	assert.True(t, db.DB.Migrator().HasColumn(&models.Transaction{}, "failure_code"))
+	assert.True(t, db.DB.Migrator().HasTable(&models.AuditEvent{}))
+
+	// Down rolls back the latest migration only
+	require.NoError(t, db.MigrateDown(1))
+	assert.False(t, db.DB.Migrator().HasTable(&models.AuditEvent{}))
+	assert.True(t, db.DB.Migrator().HasColumn(&models.Transaction{}, "failure_code"))
+
+	require.NoError(t, db.MigrateDown(1))
+	assert.False(t, db.DB.Migrator().HasColumn(&models.Transaction{}, "failure_code"))
+	assert.True(t, db.DB.Migrator().HasTable(&models.Transaction{}))
+
+	// To 0 rolls back everything, to latest applies everything again
+	require.NoError(t, db.MigrateTo(0))
+	assert.False(t, db.DB.Migrator().HasTable(&models.Transaction{}))
+
+	require.NoError(t, db.MigrateTo(3))
+	assert.True(t, db.DB.Migrator().HasColumn(&models.Transaction{}, "failure_code"))
+	assert.True(t, db.DB.Migrator().HasTable(&models.AuditEvent{}))
+
+	assert.Error(t, db.MigrateTo(99))
+}
+
+func TestMigrateAutoMigratedDatabase(t *testing.T) {
+	db := newTestDatabase(t, false)
+
+	// Databases of versions before migrations were created by AutoMigrate,
+	// which already added the failure columns and index
+	require.NoError(t, db.DB.AutoMigrate(&models.Transaction{}))
+	existing := models.Transaction{UserID: 1, Amount: 10, Status: models.StatusFailed, FailureCode: "timeout"}
+	require.NoError(t, db.DB.Create(&existing).Error)
+
+	require.NoError(t, db.Migrate())
+
+	statuses, err := db.MigrationStatus()
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.True(t, status.Applied, status.Name)
+	}
+
+	var migrated models.Transaction
+	require.NoError(t, db.DB.First(&migrated, existing.ID).Error)
+	assert.Equal(t, "timeout", migrated.FailureCode)
+	assert.True(t, db.DB.Migrator().HasTable(&models.AuditEvent{}))
+}
+
+func TestLoadMigrations(t *testing.T) {
+	for _, dialect := range []string{"mysql", "postgres", "sqlite"} {
+		migrations, err := loadMigrations(dialect)
+		require.NoError(t, err, dialect)
+		require.NotEmpty(t, migrations, dialect)
+
+		for i, migration := range migrations {
+			assert.Equal(t, uint(i+1), migration.Version, dialect)
+			assert.NotEmpty(t, splitStatements(migration.Up), dialect)
+			assert.NotEmpty(t, splitStatements(migration.Down), dialect)
+		}
+	}
+
+	_, err := loadMigrations("oracle")
+	assert.Error(t, err)
+}
+
+func TestNewDialector(t *testing.T) {
+	tests := []struct {
+		driver  string
+		dialect string
+	}{
+		{driver: "mysql", dialect: "mysql"},
+		{driver: "postgres", dialect: "postgres"},
+		{driver: "sqlite", dialect: "sqlite"},
+	}
+
+	for _, tt := range tests {
+		dialector, err := newDialector(config.DatabaseConfig{Driver: tt.driver, Name: "transaction_db"})
+		assert.NoError(t, err)
+		assert.Equal(t, tt.dialect, dialector.Name())
+	}
+
+	_, err := newDialector(config.DatabaseConfig{Driver: "oracle"})
+	assert.Error(t, err)
+}
+
+func TestOpenWithRetry(t *testing.T) {
+	cfg := config.DatabaseConfig{
+		Driver:               "postgres",
+		Host:                 "127.0.0.1",
+		Port:                 1,
+		ConnectMaxWait:       300 * time.Millisecond,
+		ConnectRetryInterval: 50 * time.Millisecond,
+	}
+
+	start := time.Now()
+	_, err := openWithRetry(cfg, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "attempts")
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	// Retry disabled fails on the first attempt
+	cfg.ConnectMaxWait = 0
+	_, err = openWithRetry(cfg, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "after 1 attempts")
+}
@@ -0,0 +1,451 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// migrationLockName identifies the lock held while migrations run
+const migrationLockName = "transaction_api_schema_migrations"
+
+// migrationLockTimeout is how long to wait for another replica's migration
+const migrationLockTimeout = 5 * time.Minute
+
+// Migration is a versioned schema change with its rollback
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	Version   uint       `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Migrate applies all pending migrations
+func (d *Database) Migrate() error {
+	migrations, err := loadMigrations(d.DB.Dialector.Name())
+	if err != nil {
+		return err
+	}
+	if len(migrations) == 0 {
+		return nil
+	}
+
+	if err := d.MigrateTo(migrations[len(migrations)-1].Version); err != nil {
+		return err
+	}
+
+	logrus.Info("Database migration completed successfully")
+	return nil
+}
+
+// MigrateDown rolls back the given number of applied migrations
+func (d *Database) MigrateDown(steps int) error {
+	return d.withMigrationLock(func(conn *sql.Conn, migrations []Migration) error {
+		applied, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+			if _, ok := applied[migrations[i].Version]; !ok {
+				continue
+			}
+			if err := d.runMigration(conn, migrations[i], false); err != nil {
+				return err
+			}
+			steps--
+		}
+		return nil
+	})
+}
+
+// MigrateTo applies or rolls back migrations until the schema is at version.
+// Version 0 rolls back every migration.
+func (d *Database) MigrateTo(version uint) error {
+	return d.withMigrationLock(func(conn *sql.Conn, migrations []Migration) error {
+		if version != 0 && findMigration(migrations, version) < 0 {
+			return fmt.Errorf("unknown migration version: %d", version)
+		}
+
+		applied, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+
+		// Roll back newer migrations, newest first
+		for i := len(migrations) - 1; i >= 0; i-- {
+			if _, ok := applied[migrations[i].Version]; ok && migrations[i].Version > version {
+				if err := d.runMigration(conn, migrations[i], false); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Apply pending migrations, oldest first
+		for _, migration := range migrations {
+			if _, ok := applied[migration.Version]; !ok && migration.Version <= version {
+				if err := d.runMigration(conn, migration, true); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// MigrationStatus lists every known migration and whether it is applied
+func (d *Database) MigrationStatus() ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := d.withMigrationConn(func(conn *sql.Conn, migrations []Migration) error {
+		applied, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			status := MigrationStatus{
+				Version: migration.Version,
+				Name:    migration.Name,
+			}
+			if appliedAt, ok := applied[migration.Version]; ok {
+				status.Applied = true
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// PendingMigrations returns the number of migrations not yet applied. It only
+// reads schema_migrations through the pool, without creating it or taking a
+// dedicated connection, so readiness probes can call it.
+func (d *Database) PendingMigrations(ctx context.Context) (int, error) {
+	migrations, err := loadMigrations(d.DB.Dialector.Name())
+	if err != nil {
+		return 0, err
+	}
+
+	db := d.DB.WithContext(ctx)
+	if !db.Migrator().HasTable("schema_migrations") {
+		return len(migrations), nil
+	}
+	var versions []uint
+	if err := db.Table("schema_migrations").Pluck("version", &versions).Error; err != nil {
+		return 0, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	applied := make(map[uint]bool, len(versions))
+	for _, version := range versions {
+		applied[version] = true
+	}
+	pending := 0
+	for _, migration := range migrations {
+		if !applied[migration.Version] {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// withMigrationConn runs fn on a dedicated connection with the schema_migrations table in place
+func (d *Database) withMigrationConn(fn func(conn *sql.Conn, migrations []Migration) error) error {
+	migrations, err := loadMigrations(d.DB.Dialector.Name())
+	if err != nil {
+		return err
+	}
+
+	sqlDB, err := d.DB.DB()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get migration connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	return fn(conn, migrations)
+}
+
+// withMigrationLock runs fn while holding a database-wide migration lock so
+// replicas starting together do not migrate concurrently
+func (d *Database) withMigrationLock(fn func(conn *sql.Conn, migrations []Migration) error) error {
+	return d.withMigrationConn(func(conn *sql.Conn, migrations []Migration) error {
+		ctx := context.Background()
+
+		switch d.DB.Dialector.Name() {
+		case "mysql":
+			var acquired sql.NullInt64
+			if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", migrationLockName, int(migrationLockTimeout.Seconds())).Scan(&acquired); err != nil {
+				return fmt.Errorf("failed to acquire migration lock: %w", err)
+			}
+			if acquired.Int64 != 1 {
+				return fmt.Errorf("timed out waiting for migration lock")
+			}
+			defer conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", migrationLockName)
+		case "postgres":
+			lockCtx, cancel := context.WithTimeout(ctx, migrationLockTimeout)
+			defer cancel()
+			if _, err := conn.ExecContext(lockCtx, "SELECT pg_advisory_lock(hashtext($1))", migrationLockName); err != nil {
+				return fmt.Errorf("failed to acquire migration lock: %w", err)
+			}
+			defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock(hashtext($1))", migrationLockName)
+		}
+		// SQLite serializes writers on the database file itself
+
+		return fn(conn, migrations)
+	})
+}
+
+// runMigration applies (up) or rolls back (down) a migration and records it
+func (d *Database) runMigration(conn *sql.Conn, migration Migration, up bool) error {
+	ctx := context.Background()
+	script, direction := migration.Down, "down"
+	if up {
+		script, direction = migration.Up, "up"
+	}
+
+	// MySQL commits DDL implicitly, so the transaction only covers the bookkeeping there
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", migration.Version, err)
+	}
+	defer tx.Rollback()
+
+	for _, statement := range splitStatements(script) {
+		if up {
+			exists, err := d.schemaObjectExists(ctx, tx, statement)
+			if err != nil {
+				return fmt.Errorf("failed to run migration %d_%s %s: %w", migration.Version, migration.Name, direction, err)
+			}
+			if exists {
+				continue
+			}
+		}
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to run migration %d_%s %s: %w", migration.Version, migration.Name, direction, err)
+		}
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, d.rebind("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)"),
+			migration.Version, migration.Name, time.Now().UTC())
+	} else {
+		_, err = tx.ExecContext(ctx, d.rebind("DELETE FROM schema_migrations WHERE version = ?"), migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", migration.Version, err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"version":   migration.Version,
+		"name":      migration.Name,
+		"direction": direction,
+	}).Info("Migration applied")
+	return nil
+}
+
+var (
+	addColumnStatement   = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(\w+)\s+ADD\s+COLUMN\s+(\w+)\s`)
+	createIndexStatement = regexp.MustCompile(`(?is)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+(\w+)\s+ON\s+(\w+)`)
+)
+
+// schemaObjectExists reports whether the column or index an ADD COLUMN or
+// CREATE INDEX statement creates already exists. Databases created by the
+// AutoMigrate of earlier versions have them, and MySQL and SQLite cannot
+// skip them with IF NOT EXISTS.
+func (d *Database) schemaObjectExists(ctx context.Context, tx *sql.Tx, statement string) (bool, error) {
+	var query string
+	var args []interface{}
+	dialect := d.DB.Dialector.Name()
+
+	if match := addColumnStatement.FindStringSubmatch(statement); match != nil {
+		args = []interface{}{match[1], match[2]}
+		switch dialect {
+		case "mysql":
+			query = "SELECT COUNT(*) FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?"
+		case "postgres":
+			query = "SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?"
+		default:
+			query = "SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?"
+		}
+	} else if match := createIndexStatement.FindStringSubmatch(statement); match != nil {
+		args = []interface{}{match[2], match[1]}
+		switch dialect {
+		case "mysql":
+			query = "SELECT COUNT(*) FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND INDEX_NAME = ?"
+		case "postgres":
+			query = "SELECT COUNT(*) FROM pg_indexes WHERE schemaname = current_schema() AND tablename = ? AND indexname = ?"
+		default:
+			query = "SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND name = ?"
+		}
+	} else {
+		return false, nil
+	}
+
+	var count int
+	if err := tx.QueryRowContext(ctx, d.rebind(query), args...).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to inspect the schema: %w", err)
+	}
+	return count > 0, nil
+}
+
+// rebind converts ? placeholders to the dialect's placeholder syntax
+func (d *Database) rebind(query string) string {
+	if d.DB.Dialector.Name() != "postgres" {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// appliedMigrations returns the applied versions and when they were applied
+func appliedMigrations(conn *sql.Conn) (map[uint]time.Time, error) {
+	rows, err := conn.QueryContext(context.Background(), "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[uint]time.Time)
+	for rows.Next() {
+		var version uint
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// loadMigrations reads the embedded migrations of a dialect, ordered by version
+func loadMigrations(dialect string) ([]Migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for database dialect %s: %w", dialect, err)
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		// File names look like 0001_create_transactions.up.sql
+		name := entry.Name()
+		var up bool
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			up = true
+		case strings.HasSuffix(name, ".down.sql"):
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(strings.TrimSuffix(name, ".up.sql"), ".down.sql")
+		versionStr, migrationName, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name: %s", name)
+		}
+		version, err := strconv.ParseUint(versionStr, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", name, err)
+		}
+
+		content, err := migrationFiles.ReadFile(path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: migrationName}
+			byVersion[uint(version)] = migration
+		}
+		if up {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down files", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// findMigration returns the index of version in migrations, or -1
+func findMigration(migrations []Migration, version uint) int {
+	for i, migration := range migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// splitStatements splits a migration script into single statements, since
+// drivers do not accept multiple statements per Exec by default
+func splitStatements(script string) []string {
+	var statements []string
+	for _, statement := range strings.Split(script, ";") {
+		if statement = strings.TrimSpace(statement); statement != "" {
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
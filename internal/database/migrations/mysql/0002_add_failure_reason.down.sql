@@ -0,0 +1,4 @@
+ALTER TABLE transactions
+    DROP INDEX idx_transactions_failure_code,
+    DROP COLUMN failure_message,
+    DROP COLUMN failure_code;
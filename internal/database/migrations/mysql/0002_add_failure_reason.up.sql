@@ -0,0 +1,3 @@
+ALTER TABLE transactions ADD COLUMN failure_code VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE transactions ADD COLUMN failure_message VARCHAR(255) NOT NULL DEFAULT '';
+CREATE INDEX idx_transactions_failure_code ON transactions (failure_code);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    sequence BIGINT UNSIGNED NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    entity_type VARCHAR(32) NOT NULL,
+    entity_id BIGINT UNSIGNED NOT NULL,
+    actor VARCHAR(255) NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    request_id VARCHAR(128) NOT NULL DEFAULT '',
+    before_state TEXT NULL,
+    after_state TEXT NULL,
+    changes TEXT NULL,
+    prev_hash VARCHAR(64) NOT NULL,
+    hash VARCHAR(64) NOT NULL,
+    created_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_audit_events_sequence (sequence),
+    INDEX idx_audit_events_entity (entity_type, entity_id),
+    INDEX idx_audit_events_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
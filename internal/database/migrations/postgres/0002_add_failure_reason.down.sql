@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transactions_failure_code;
+ALTER TABLE transactions DROP COLUMN IF EXISTS failure_message;
+ALTER TABLE transactions DROP COLUMN IF EXISTS failure_code;
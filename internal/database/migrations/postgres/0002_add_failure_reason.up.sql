@@ -0,0 +1,3 @@
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS failure_code VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS failure_message VARCHAR(255) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_transactions_failure_code ON transactions (failure_code);
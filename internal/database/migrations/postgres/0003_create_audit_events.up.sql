@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    sequence BIGINT NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    entity_type VARCHAR(32) NOT NULL,
+    entity_id BIGINT NOT NULL,
+    actor VARCHAR(255) NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    request_id VARCHAR(128) NOT NULL DEFAULT '',
+    before_state TEXT,
+    after_state TEXT,
+    changes TEXT,
+    prev_hash VARCHAR(64) NOT NULL,
+    hash VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_events_sequence ON audit_events (sequence);
+CREATE INDEX IF NOT EXISTS idx_audit_events_entity ON audit_events (entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events (created_at);
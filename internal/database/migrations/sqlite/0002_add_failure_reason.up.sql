@@ -0,0 +1,3 @@
+ALTER TABLE transactions ADD COLUMN failure_code TEXT NOT NULL DEFAULT '';
+ALTER TABLE transactions ADD COLUMN failure_message TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_transactions_failure_code ON transactions (failure_code);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    sequence INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    entity_type TEXT NOT NULL,
+    entity_id INTEGER NOT NULL,
+    actor TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    request_id TEXT NOT NULL DEFAULT '',
+    before_state TEXT,
+    after_state TEXT,
+    changes TEXT,
+    prev_hash TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    created_at DATETIME
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_events_sequence ON audit_events (sequence);
+CREATE INDEX IF NOT EXISTS idx_audit_events_entity ON audit_events (entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events (created_at);
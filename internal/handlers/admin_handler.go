@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"transaction-api/internal/models"
+	"transaction-api/internal/reload"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	reloader *reload.Reloader
+}
+
+func NewAdminHandler(reloader *reload.Reloader) *AdminHandler {
+	return &AdminHandler{reloader: reloader}
+}
+
+// GetReloadStatus reports the last configuration reload
+// @Summary Get configuration reload status
+// @Description Get when the configuration was last reloaded on SIGHUP, which changed settings were applied, which were rejected because they need a restart, and which were invalid. Served on the admin listener only.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.ConfigReloadStatusResponse
+// @Router /admin/reload [get]
+func (h *AdminHandler) GetReloadStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, models.ConfigReloadStatusResponse{LastReload: h.reloader.Last()})
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"transaction-api/internal/config"
+	"transaction-api/internal/models"
+	"transaction-api/internal/reload"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getReloadStatus(t *testing.T, router *gin.Engine) models.ConfigReloadStatusResponse {
+	req := httptest.NewRequest(http.MethodGet, "/admin/reload", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response models.ConfigReloadStatusResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+func TestGetReloadStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("cors:\n  max_age: 10m\n"), 0o600))
+	t.Setenv("CONFIG_FILE", path)
+	loader := config.NewLoader()
+	_, err := loader.Load()
+	require.NoError(t, err)
+
+	reloader := reload.New(loader)
+	router := gin.New()
+	router.GET("/admin/reload", NewAdminHandler(reloader).GetReloadStatus)
+
+	assert.Nil(t, getReloadStatus(t, router).LastReload)
+
+	require.NoError(t, os.WriteFile(path, []byte("cors:\n  max_age: 1h\ndatabase:\n  name: other\n"), 0o600))
+	reloader.Reload()
+
+	status := getReloadStatus(t, router)
+	require.NotNil(t, status.LastReload)
+	assert.Equal(t, models.ConfigReloadApplied, status.LastReload.Status)
+	assert.Equal(t, []string{"CORS_MAX_AGE"}, status.LastReload.Applied)
+	assert.Equal(t, []string{"DB_NAME"}, status.LastReload.Rejected)
+	assert.False(t, status.LastReload.ReloadedAt.IsZero())
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"transaction-api/internal/middleware"
+	"transaction-api/internal/models"
+	"transaction-api/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	service *services.AuditService
+}
+
+func NewAuditHandler(service *services.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// GetAuditEvents retrieves the audit log of transaction mutations
+// @Summary Get audit events
+// @Description Get the hash-chained audit events recorded for every transaction create, update and delete, newest first
+// @Tags audit
+// @Accept json
+// @Produce json
+// @Param entity_id query int false "Filter by transaction ID"
+// @Param action query string false "Filter by action" Enums(create, update, delete)
+// @Param actor query string false "Filter by actor"
+// @Param request_id query string false "Filter by request ID"
+// @Param from query string false "Start of the period (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "End of the period, exclusive (RFC3339 or YYYY-MM-DD, a date covers the whole day)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(50)
+// @Success 200 {object} models.AuditEventResponse
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /audit [get]
+func (h *AuditHandler) GetAuditEvents(c *gin.Context) {
+	var query models.AuditQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		middleware.SendValidationError(c, err)
+		return
+	}
+
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		middleware.SendError(c, http.StatusBadRequest, "invalid_date_range", err.Error())
+		return
+	}
+	query.From = from
+	query.To = to
+
+	response, err := h.service.GetAuditEvents(c.Request.Context(), &query)
+	if err != nil {
+		middleware.SendServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"transaction-api/internal/audit"
+	"transaction-api/internal/middleware"
+	"transaction-api/internal/models"
+	"transaction-api/internal/repository"
+	"transaction-api/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuditRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	repo := repository.NewMemoryTransactionRepository()
+	transactionHandler := NewTransactionHandler(services.NewTransactionServiceWithRepository(repo))
+	auditHandler := NewAuditHandler(services.NewAuditService(repo))
+
+	router := gin.New()
+	require.NoError(t, router.SetTrustedProxies(nil))
+	router.Use(middleware.RequestID())
+	router.Use(middleware.AuditActor())
+	router.POST("/transactions", transactionHandler.CreateTransaction)
+	router.PUT("/transactions/:id", transactionHandler.UpdateTransaction)
+	router.GET("/audit", auditHandler.GetAuditEvents)
+	return router
+}
+
+func TestGetAuditEvents(t *testing.T) {
+	router := newAuditRouter(t)
+
+	body, _ := json.Marshal(models.TransactionRequest{UserID: 1, Amount: 100})
+	req := httptest.NewRequest(http.MethodPost, "/transactions", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middleware.RequestIDHeader, "create-1")
+	req.RemoteAddr = "192.0.2.10:1234"
+	// Untrusted clients cannot choose the recorded IP
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	req = httptest.NewRequest(http.MethodPut, "/transactions/1", bytes.NewBufferString(`{"status": "success"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data []struct {
+			Sequence  uint64                 `json:"sequence"`
+			Action    string                 `json:"action"`
+			EntityID  uint                   `json:"entity_id"`
+			Actor     string                 `json:"actor"`
+			IP        string                 `json:"ip"`
+			RequestID string                 `json:"request_id"`
+			Before    map[string]interface{} `json:"before"`
+			After     map[string]interface{} `json:"after"`
+			Changes   map[string]interface{} `json:"changes"`
+			Hash      string                 `json:"hash"`
+		} `json:"data"`
+		Total int64 `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(2), response.Total)
+	require.Len(t, response.Data, 2)
+
+	// Newest first
+	update, create := response.Data[0], response.Data[1]
+	assert.Equal(t, "update", update.Action)
+	assert.Equal(t, uint64(2), update.Sequence)
+	assert.Contains(t, update.Changes, "status")
+	assert.Equal(t, "create", create.Action)
+	assert.Equal(t, uint(1), create.EntityID)
+	assert.Equal(t, audit.AnonymousActor, create.Actor)
+	assert.Equal(t, "192.0.2.10", create.IP)
+	assert.Equal(t, "create-1", create.RequestID)
+	assert.Nil(t, create.Before)
+	assert.Equal(t, 100.0, create.After["amount"])
+	assert.NotEmpty(t, create.Hash)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit?request_id=create-1", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(1), response.Total)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit?action=rename", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit?from=yesterday", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"transaction-api/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultCheckTimeout bounds each readiness check
+const DefaultCheckTimeout = 2 * time.Second
+
+type HealthHandler struct {
+	db           *database.Database
+	mu           sync.RWMutex
+	checks       []readinessCheck
+	checkTimeout time.Duration
+	shuttingDown atomic.Bool
+}
+
+// readinessCheck is a dependency check run by the readiness endpoint.
+// A failing critical check makes the service not ready, a failing
+// non-critical check only degrades it.
+type readinessCheck struct {
+	name     string
+	critical bool
+	check    func(ctx context.Context) error
+}
+
+func NewHealthHandler(db *database.Database) *HealthHandler {
+	h := &HealthHandler{db: db, checkTimeout: DefaultCheckTimeout}
+	h.AddCheck("database", true, db.PingContext)
+	h.AddCheck("migrations", true, h.checkMigrations)
+	h.AddCheck("connection_pool", false, h.checkPool)
+	return h
+}
+
+// SetCheckTimeout changes how long each readiness check may take before it
+// fails
+func (h *HealthHandler) SetCheckTimeout(timeout time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checkTimeout = timeout
+}
+
+// AddCheck registers a readiness check, e.g. for a background worker. The
+// check should give up when ctx is done; one that does not is abandoned
+// after the check timeout.
+func (h *HealthHandler) AddCheck(name string, critical bool, check func(ctx context.Context) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, readinessCheck{name: name, critical: critical, check: check})
+}
+
+// MarkShuttingDown makes the readiness endpoint report not ready so load
+// balancers stop routing new requests while the server drains
+func (h *HealthHandler) MarkShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// DatabaseHealth represents the database connectivity and pool statistics
+type DatabaseHealth struct {
+	Status  string              `json:"status"`
+	Latency string              `json:"latency"`
+	Error   string              `json:"error,omitempty"`
+	Pool    *database.PoolStats `json:"pool,omitempty"`
+}
+
+// CheckResult represents the outcome of a single readiness check
+type CheckResult struct {
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+	Latency  string `json:"latency"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ReadinessResponse represents the readiness endpoint response
+type ReadinessResponse struct {
+	Status    string                 `json:"status"`
+	Timestamp time.Time              `json:"timestamp"`
+	Checks    map[string]CheckResult `json:"checks"`
+}
+
+// HealthCheck provides a health check endpoint
+// @Summary Health check
+// @Description Check if the service and its database are healthy
+// @Tags health
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /health [get]
+func (h *HealthHandler) HealthCheck(c *gin.Context) {
+	status, statusCode := "healthy", http.StatusOK
+	dbHealth := h.checkDatabase(c.Request.Context())
+	if dbHealth.Status != "up" {
+		status, statusCode = "unhealthy", http.StatusServiceUnavailable
+	}
+
+	c.JSON(statusCode, gin.H{
+		"status":    status,
+		"service":   "transaction-api",
+		"timestamp": time.Now().UTC(),
+		"database":  dbHealth,
+	})
+}
+
+// Live reports whether the process is running
+// @Summary Liveness probe
+// @Description Check if the process is alive, without checking dependencies
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /health/live [get]
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "alive",
+		"timestamp": time.Now().UTC(),
+	})
+}
+
+// Ready reports whether the service can serve traffic
+// @Summary Readiness probe
+// @Description Check database connectivity, migration state and background workers
+// @Tags health
+// @Produce json
+// @Success 200 {object} ReadinessResponse
+// @Failure 503 {object} ReadinessResponse
+// @Router /health/ready [get]
+func (h *HealthHandler) Ready(c *gin.Context) {
+	response := ReadinessResponse{
+		Status:    "ready",
+		Timestamp: time.Now().UTC(),
+		Checks:    make(map[string]CheckResult),
+	}
+
+	h.mu.RLock()
+	checks, timeout := h.checks, h.checkTimeout
+	h.mu.RUnlock()
+
+	for _, check := range checks {
+		result := CheckResult{Status: "pass", Critical: check.critical}
+
+		start := time.Now()
+		if err := runCheck(c.Request.Context(), check.check, timeout); err != nil {
+			result.Status = "fail"
+			result.Error = err.Error()
+			if check.critical {
+				response.Status = "not_ready"
+			} else if response.Status == "ready" {
+				response.Status = "degraded"
+			}
+		}
+		result.Latency = time.Since(start).String()
+
+		response.Checks[check.name] = result
+	}
+
+	if h.shuttingDown.Load() {
+		response.Status = "not_ready"
+		response.Checks["shutdown"] = CheckResult{Status: "fail", Critical: true, Error: "server is shutting down"}
+	}
+
+	statusCode := http.StatusOK
+	if response.Status == "not_ready" {
+		statusCode = http.StatusServiceUnavailable
+	}
+	c.JSON(statusCode, response)
+}
+
+// runCheck runs check with a timeout. A check that ignores its context is
+// left running and reported as timed out, so a hung dependency cannot hang
+// the probe.
+func runCheck(ctx context.Context, check func(ctx context.Context) error, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- check(ctx) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("timed out after %s", timeout)
+		}
+		return ctx.Err()
+	}
+}
+
+// checkDatabase pings the database, within the check timeout, and collects
+// the pool statistics
+func (h *HealthHandler) checkDatabase(ctx context.Context) DatabaseHealth {
+	health := DatabaseHealth{Status: "up"}
+
+	h.mu.RLock()
+	timeout := h.checkTimeout
+	h.mu.RUnlock()
+
+	start := time.Now()
+	if err := runCheck(ctx, h.db.PingContext, timeout); err != nil {
+		health.Status = "down"
+		health.Error = err.Error()
+	}
+	health.Latency = time.Since(start).String()
+
+	if stats, err := h.db.Stats(); err == nil {
+		health.Pool = &stats
+	}
+
+	return health
+}
+
+// checkMigrations fails while migrations are pending
+func (h *HealthHandler) checkMigrations(ctx context.Context) error {
+	pending, err := h.db.PendingMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	if pending > 0 {
+		return fmt.Errorf("%d pending migrations", pending)
+	}
+	return nil
+}
+
+// checkPool fails when every connection is in use and requests are waiting
+func (h *HealthHandler) checkPool(context.Context) error {
+	stats, err := h.db.Stats()
+	if err != nil {
+		return err
+	}
+	if stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections {
+		return fmt.Errorf("connection pool exhausted: %d of %d connections in use", stats.InUse, stats.MaxOpenConnections)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"transaction-api/internal/config"
+	"transaction-api/internal/database"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHealthRouter(t *testing.T, autoMigrate bool) (*gin.Engine, *HealthHandler, *database.Database) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := database.NewDatabase(&config.Config{
+		Database: config.DatabaseConfig{Driver: "sqlite", DSN: ":memory:", AutoMigrate: autoMigrate},
+		Server:   config.ServerConfig{GinMode: "release"},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	handler := NewHealthHandler(db)
+	router := gin.New()
+	router.GET("/health", handler.HealthCheck)
+	router.GET("/health/live", handler.Live)
+	router.GET("/health/ready", handler.Ready)
+
+	return router, handler, db
+}
+
+func getReadiness(t *testing.T, router *gin.Engine) (int, ReadinessResponse) {
+	req, _ := http.NewRequest("GET", "/health/ready", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	var response ReadinessResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	return w.Code, response
+}
+
+func TestHealthCheck(t *testing.T) {
+	router, _, _ := newHealthRouter(t, true)
+
+	req, _ := http.NewRequest("GET", "/health", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "healthy", response["status"])
+	assert.Equal(t, "transaction-api", response["service"])
+
+	dbHealth := response["database"].(map[string]interface{})
+	assert.Equal(t, "up", dbHealth["status"])
+	assert.Contains(t, dbHealth, "pool")
+}
+
+func TestHealthCheckDatabaseDown(t *testing.T) {
+	router, _, db := newHealthRouter(t, true)
+	require.NoError(t, db.Close())
+
+	req, _ := http.NewRequest("GET", "/health", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "unhealthy", response["status"])
+
+	dbHealth := response["database"].(map[string]interface{})
+	assert.Equal(t, "down", dbHealth["status"])
+	assert.NotEmpty(t, dbHealth["error"])
+}
+
+func TestHealthCheckTimeout(t *testing.T) {
+	router, handler, _ := newHealthRouter(t, true)
+	handler.SetCheckTimeout(time.Nanosecond)
+
+	// A database that does not answer in time is reported down
+	req, _ := http.NewRequest("GET", "/health", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	dbHealth := response["database"].(map[string]interface{})
+	assert.Equal(t, "down", dbHealth["status"])
+}
+
+func TestLiveness(t *testing.T) {
+	router, _, db := newHealthRouter(t, true)
+	require.NoError(t, db.Close())
+
+	// Liveness does not depend on the database
+	req, _ := http.NewRequest("GET", "/health/live", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestReadiness(t *testing.T) {
+	router, handler, _ := newHealthRouter(t, true)
+
+	code, response := getReadiness(t, router)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", response.Status)
+	assert.Equal(t, "pass", response.Checks["database"].Status)
+	assert.Equal(t, "pass", response.Checks["migrations"].Status)
+	assert.NotEmpty(t, response.Checks["database"].Latency)
+
+	// A failing non-critical check degrades the service
+	handler.AddCheck("webhook_worker", false, func(context.Context) error { return fmt.Errorf("worker stalled") })
+	code, response = getReadiness(t, router)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "degraded", response.Status)
+	assert.Equal(t, "worker stalled", response.Checks["webhook_worker"].Error)
+
+	// Shutting down makes the service not ready
+	handler.MarkShuttingDown()
+	code, response = getReadiness(t, router)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not_ready", response.Status)
+}
+
+func TestReadinessPendingMigrations(t *testing.T) {
+	router, _, _ := newHealthRouter(t, false)
+
+	code, response := getReadiness(t, router)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not_ready", response.Status)
+	assert.Equal(t, "fail", response.Checks["migrations"].Status)
+	assert.Contains(t, response.Checks["migrations"].Error, "pending migrations")
+}
+
+func TestReadinessCheckTimeout(t *testing.T) {
+	router, handler, _ := newHealthRouter(t, true)
+	handler.SetCheckTimeout(50 * time.Millisecond)
+
+	// A hung critical dependency makes the service not ready instead of
+	// hanging the probe, even when the check ignores its context
+	release := make(chan struct{})
+	defer close(release)
+	handler.AddCheck("ledger", true, func(context.Context) error {
+		<-release
+		return nil
+	})
+
+	start := time.Now()
+	code, response := getReadiness(t, router)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not_ready", response.Status)
+	assert.Equal(t, "timed out after 50ms", response.Checks["ledger"].Error)
+	assert.Equal(t, "pass", response.Checks["database"].Status)
+}
+
+func TestReadinessDoesNotCreateTables(t *testing.T) {
+	router, _, db := newHealthRouter(t, false)
+
+	code, _ := getReadiness(t, router)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.False(t, db.DB.Migrator().HasTable("schema_migrations"))
+}
+
+func TestReadinessDatabaseDown(t *testing.T) {
+	router, _, db := newHealthRouter(t, true)
+	require.NoError(t, db.Close())
+
+	code, response := getReadiness(t, router)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "fail", response.Checks["database"].Status)
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"transaction-api/internal/middleware"
+	"transaction-api/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RateLimitHandler struct {
+	limiter *ratelimit.Limiter
+}
+
+func NewRateLimitHandler(limiter *ratelimit.Limiter) *RateLimitHandler {
+	return &RateLimitHandler{limiter: limiter}
+}
+
+// GetUsage reports the caller's rate limit and daily quota usage
+// @Summary Get rate limit usage
+// @Description Get the remaining requests and daily quota usage of the calling client under every rate limit policy. Clients are identified by API key, user or IP.
+// @Tags rate-limit
+// @Produce json
+// @Success 200 {object} models.RateLimitUsageResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /rate-limit [get]
+func (h *RateLimitHandler) GetUsage(c *gin.Context) {
+	response, err := h.limiter.Usage(c.Request.Context(), c.GetString(middleware.RateLimitClientKey))
+	if err != nil {
+		middleware.SendServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
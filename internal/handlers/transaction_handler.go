@@ -1,17 +1,19 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"transaction-api/internal/i18n"
+	"transaction-api/internal/logging"
 	"transaction-api/internal/middleware"
 	"transaction-api/internal/models"
 	"transaction-api/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"github.com/sirupsen/logrus"
 )
 
 type TransactionHandler struct {
@@ -22,10 +24,19 @@ type TransactionHandler struct {
 func NewTransactionHandler(service *services.TransactionService) *TransactionHandler {
 	return &TransactionHandler{
 		service:   service,
-		validator: validator.New(),
+		validator: i18n.Validator(),
 	}
 }
 
+// readService returns the service to use for reads, routed to the primary
+// database when the request needs read-your-writes consistency
+func (h *TransactionHandler) readService(c *gin.Context) *services.TransactionService {
+	if c.GetBool(middleware.ReadFromPrimaryKey) {
+		return h.service.WithPrimary()
+	}
+	return h.service
+}
+
 // CreateTransaction creates a new transaction
 // @Summary Create transaction
 // @Description Create a new transaction
@@ -40,18 +51,18 @@ func NewTransactionHandler(service *services.TransactionService) *TransactionHan
 func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
 	var req models.TransactionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		middleware.SendValidationError(c, err.Error())
+		middleware.SendValidationError(c, err)
 		return
 	}
 
 	if err := h.validator.Struct(&req); err != nil {
-		middleware.SendValidationError(c, err.Error())
+		middleware.SendValidationError(c, err)
 		return
 	}
 
-	transaction, err := h.service.CreateTransaction(&req)
+	transaction, err := h.service.CreateTransaction(c.Request.Context(), &req)
 	if err != nil {
-		middleware.SendError(c, http.StatusInternalServerError, "internal_server_error", err.Error())
+		middleware.SendServiceError(c, err)
 		return
 	}
 
@@ -78,13 +89,9 @@ func (h *TransactionHandler) GetTransactionByID(c *gin.Context) {
 		return
 	}
 
-	transaction, err := h.service.GetTransactionByID(uint(id))
+	transaction, err := h.readService(c).GetTransactionByID(c.Request.Context(), uint(id))
 	if err != nil {
-		if err.Error() == "transaction not found" {
-			middleware.SendError(c, http.StatusNotFound, "not_found", "Transaction not found")
-			return
-		}
-		middleware.SendError(c, http.StatusInternalServerError, "internal_server_error", err.Error())
+		middleware.SendServiceError(c, err)
 		return
 	}
 
@@ -108,21 +115,19 @@ func (h *TransactionHandler) GetTransactionByID(c *gin.Context) {
 func (h *TransactionHandler) GetTransactions(c *gin.Context) {
 	var query models.TransactionQuery
 	if err := c.ShouldBindQuery(&query); err != nil {
-		middleware.SendValidationError(c, err.Error())
+		middleware.SendValidationError(c, err)
 		return
 	}
 
 	// Validate status if provided
-	if query.Status != "" {
-		if query.Status != models.StatusPending && query.Status != models.StatusSuccess && query.Status != models.StatusFailed {
-			middleware.SendError(c, http.StatusBadRequest, "invalid_status", "Status must be one of: pending, success, failed")
-			return
-		}
+	if query.Status != "" && !isValidStatus(query.Status) {
+		middleware.SendError(c, http.StatusBadRequest, "invalid_status", "Status must be one of: pending, success, failed")
+		return
 	}
 
-	response, err := h.service.GetTransactions(&query)
+	response, err := h.readService(c).GetTransactions(c.Request.Context(), &query)
 	if err != nil {
-		middleware.SendError(c, http.StatusInternalServerError, "internal_server_error", err.Error())
+		middleware.SendServiceError(c, err)
 		return
 	}
 
@@ -131,7 +136,7 @@ func (h *TransactionHandler) GetTransactions(c *gin.Context) {
 
 // UpdateTransaction updates a transaction status
 // @Summary Update transaction
-// @Description Update transaction status
+// @Description Update transaction status. A failure_code from the catalog is required when the status is failed.
 // @Tags transactions
 // @Accept json
 // @Produce json
@@ -152,22 +157,18 @@ func (h *TransactionHandler) UpdateTransaction(c *gin.Context) {
 
 	var req models.TransactionUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		middleware.SendValidationError(c, err.Error())
+		middleware.SendValidationError(c, err)
 		return
 	}
 
 	if err := h.validator.Struct(&req); err != nil {
-		middleware.SendValidationError(c, err.Error())
+		middleware.SendValidationError(c, err)
 		return
 	}
 
-	transaction, err := h.service.UpdateTransaction(uint(id), &req)
+	transaction, err := h.service.UpdateTransaction(c.Request.Context(), uint(id), &req)
 	if err != nil {
-		if err.Error() == "transaction not found" {
-			middleware.SendError(c, http.StatusNotFound, "not_found", "Transaction not found")
-			return
-		}
-		middleware.SendError(c, http.StatusInternalServerError, "internal_server_error", err.Error())
+		middleware.SendServiceError(c, err)
 		return
 	}
 
@@ -194,13 +195,9 @@ func (h *TransactionHandler) DeleteTransaction(c *gin.Context) {
 		return
 	}
 
-	err = h.service.DeleteTransaction(uint(id))
+	err = h.service.DeleteTransaction(c.Request.Context(), uint(id))
 	if err != nil {
-		if err.Error() == "transaction not found" {
-			middleware.SendError(c, http.StatusNotFound, "not_found", "Transaction not found")
-			return
-		}
-		middleware.SendError(c, http.StatusInternalServerError, "internal_server_error", err.Error())
+		middleware.SendServiceError(c, err)
 		return
 	}
 
@@ -213,32 +210,290 @@ func (h *TransactionHandler) DeleteTransaction(c *gin.Context) {
 // @Tags dashboard
 // @Accept json
 // @Produce json
+// @Param from query string false "Period start (RFC3339 or YYYY-MM-DD), defaults to today"
+// @Param to query string false "Period end (RFC3339 or YYYY-MM-DD, inclusive day), defaults to now"
+// @Param compare query string false "Compare with another period" Enums(previous_period, previous_year)
+// @Param top_users query int false "Include the top N users by successful amount"
 // @Success 200 {object} models.DashboardSummary
+// @Failure 400 {object} middleware.ErrorResponse
 // @Failure 500 {object} middleware.ErrorResponse
 // @Router /dashboard/summary [get]
 func (h *TransactionHandler) GetDashboardSummary(c *gin.Context) {
-	summary, err := h.service.GetDashboardSummary()
+	from, to, err := parseDateRange(c)
 	if err != nil {
-		logrus.WithError(err).Error("Failed to get dashboard summary")
-		middleware.SendError(c, http.StatusInternalServerError, "internal_server_error", err.Error())
+		middleware.SendError(c, http.StatusBadRequest, "invalid_date_range", err.Error())
+		return
+	}
+
+	query := models.DashboardQuery{
+		From:    from,
+		To:      to,
+		Compare: models.CompareMode(c.Query("compare")),
+	}
+	if topUsers := c.Query("top_users"); topUsers != "" {
+		query.TopUsers, err = strconv.Atoi(topUsers)
+		if err != nil || query.TopUsers < 0 {
+			middleware.SendError(c, http.StatusBadRequest, "invalid_top_users", "Top users must be a non-negative integer")
+			return
+		}
+	}
+	if query.Compare != "" && query.Compare != models.ComparePreviousPeriod && query.Compare != models.ComparePreviousYear {
+		middleware.SendError(c, http.StatusBadRequest, "invalid_compare", "Compare must be one of: previous_period, previous_year")
+		return
+	}
+
+	summary, err := h.readService(c).GetDashboardSummary(c.Request.Context(), &query)
+	if err != nil {
+		logging.FromContext(c.Request.Context()).WithError(err).Error("Failed to get dashboard summary")
+		middleware.SendServiceError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, summary)
 }
 
-// HealthCheck provides a health check endpoint
-// @Summary Health check
-// @Description Check if the service is healthy
-// @Tags health
+// GetAmountDistribution retrieves the amount histogram and percentiles
+// @Summary Get amount distribution
+// @Description Get amount histogram buckets and percentiles over filtered transactions
+// @Tags dashboard
+// @Accept json
+// @Produce json
+// @Param user_id query int false "Filter by User ID"
+// @Param status query string false "Filter by Status" Enums(pending, success, failed)
+// @Param from query string false "Range start (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "Range end (RFC3339 or YYYY-MM-DD, inclusive day)"
+// @Param buckets query int false "Number of buckets" default(10)
+// @Param scale query string false "Bucket scale" Enums(linear, log) default(linear)
+// @Param min query number false "Lower bound of the first bucket"
+// @Param max query number false "Upper bound of the last bucket"
+// @Success 200 {object} models.AmountDistribution
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /dashboard/distribution [get]
+func (h *TransactionHandler) GetAmountDistribution(c *gin.Context) {
+	var query models.DistributionQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		middleware.SendValidationError(c, err)
+		return
+	}
+
+	if query.Status != "" && !isValidStatus(query.Status) {
+		middleware.SendError(c, http.StatusBadRequest, "invalid_status", "Status must be one of: pending, success, failed")
+		return
+	}
+	if query.Scale != "" && query.Scale != models.ScaleLinear && query.Scale != models.ScaleLogarithmic {
+		middleware.SendError(c, http.StatusBadRequest, "invalid_scale", "Scale must be one of: linear, log")
+		return
+	}
+	if query.Min != nil && query.Max != nil && *query.Min > *query.Max {
+		middleware.SendError(c, http.StatusBadRequest, "invalid_range", "Min must not be greater than max")
+		return
+	}
+	if query.Scale == models.ScaleLogarithmic && query.Min != nil && *query.Min <= 0 {
+		middleware.SendError(c, http.StatusBadRequest, "invalid_range", "Min must be positive for logarithmic scale")
+		return
+	}
+
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		middleware.SendError(c, http.StatusBadRequest, "invalid_date_range", err.Error())
+		return
+	}
+	query.From = from
+	query.To = to
+
+	distribution, err := h.readService(c).GetAmountDistribution(c.Request.Context(), &query)
+	if err != nil {
+		logging.FromContext(c.Request.Context()).WithError(err).Error("Failed to get amount distribution")
+		middleware.SendServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, distribution)
+}
+
+// GetTopUsers retrieves the top users leaderboard
+// @Summary Get top users
+// @Description Get the users with the highest amount or transaction count
+// @Tags dashboard
+// @Accept json
+// @Produce json
+// @Param by query string false "Ranking metric" Enums(amount, count) default(amount)
+// @Param status query string false "Filter by Status" Enums(pending, success, failed)
+// @Param from query string false "Range start (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "Range end (RFC3339 or YYYY-MM-DD, inclusive day)"
+// @Param limit query int false "Number of users" default(10)
+// @Success 200 {object} models.TopUsersResponse
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /dashboard/top-users [get]
+func (h *TransactionHandler) GetTopUsers(c *gin.Context) {
+	var query models.TopUsersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		middleware.SendValidationError(c, err)
+		return
+	}
+
+	if query.By != "" && query.By != models.TopUsersByAmount && query.By != models.TopUsersByCount {
+		middleware.SendError(c, http.StatusBadRequest, "invalid_by", "By must be one of: amount, count")
+		return
+	}
+	if query.Status != "" && !isValidStatus(query.Status) {
+		middleware.SendError(c, http.StatusBadRequest, "invalid_status", "Status must be one of: pending, success, failed")
+		return
+	}
+
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		middleware.SendError(c, http.StatusBadRequest, "invalid_date_range", err.Error())
+		return
+	}
+	query.From = from
+	query.To = to
+
+	response, err := h.readService(c).GetTopUsers(c.Request.Context(), &query)
+	if err != nil {
+		logging.FromContext(c.Request.Context()).WithError(err).Error("Failed to get top users")
+		middleware.SendServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetFailureBreakdown retrieves failures by code over time
+// @Summary Get failure breakdown
+// @Description Get failure counts by failure code and failure rate per time bucket
+// @Tags dashboard
 // @Accept json
 // @Produce json
-// @Success 200 {object} map[string]string
-// @Router /health [get]
-func (h *TransactionHandler) HealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status":    "healthy",
-		"service":   "transaction-api",
-		"timestamp": time.Now().UTC(),
-	})
-}
\ No newline at end of file
+// @Param from query string false "Range start (RFC3339 or YYYY-MM-DD), defaults to 7 days (day) or 24 hours (hour) before to"
+// @Param to query string false "Range end (RFC3339 or YYYY-MM-DD, inclusive day), defaults to now"
+// @Param interval query string false "Time bucket size" Enums(hour, day) default(day)
+// @Success 200 {object} models.FailureBreakdown
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /dashboard/failures [get]
+func (h *TransactionHandler) GetFailureBreakdown(c *gin.Context) {
+	query := models.FailureQuery{
+		Interval: models.FailureInterval(c.DefaultQuery("interval", string(models.IntervalDay))),
+	}
+	if query.Interval != models.IntervalHour && query.Interval != models.IntervalDay {
+		middleware.SendError(c, http.StatusBadRequest, "invalid_interval", "Interval must be one of: hour, day")
+		return
+	}
+
+	// Unlike the other ranges, from defaults from to and the interval
+	from, to, err := parseDateBounds(c)
+	if err != nil {
+		middleware.SendError(c, http.StatusBadRequest, "invalid_date_range", err.Error())
+		return
+	}
+
+	query.To = time.Now().UTC()
+	if to != nil {
+		query.To = to.UTC()
+	}
+	if from != nil {
+		query.From = from.UTC()
+	} else if query.Interval == models.IntervalHour {
+		query.From = query.To.Add(-24 * time.Hour)
+	} else {
+		query.From = query.To.AddDate(0, 0, -7)
+	}
+
+	breakdown, err := h.readService(c).GetFailureBreakdown(c.Request.Context(), &query)
+	if err != nil {
+		logging.FromContext(c.Request.Context()).WithError(err).Error("Failed to get failure breakdown")
+		middleware.SendServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, breakdown)
+}
+
+// GetUserSummary retrieves transaction statistics for a single user
+// @Summary Get user summary
+// @Description Get transaction statistics for a specific user
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} models.UserSummary
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /users/{id}/summary [get]
+func (h *TransactionHandler) GetUserSummary(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		middleware.SendError(c, http.StatusBadRequest, "invalid_id", "Invalid user ID")
+		return
+	}
+
+	summary, err := h.readService(c).GetUserSummary(c.Request.Context(), uint(id))
+	if err != nil {
+		logging.FromContext(c.Request.Context()).WithError(err).Error("Failed to get user summary")
+		middleware.SendServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// isValidStatus reports whether status is a known transaction status
+func isValidStatus(status models.TransactionStatus) bool {
+	return status == models.StatusPending || status == models.StatusSuccess || status == models.StatusFailed
+}
+
+// parseDateRange parses the optional from and to query parameters.
+// A date-only to value covers the whole day. From is required when to is set.
+func parseDateRange(c *gin.Context) (*time.Time, *time.Time, error) {
+	from, to, err := parseDateBounds(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	if to != nil && from == nil {
+		return nil, nil, fmt.Errorf("from is required when to is set")
+	}
+	return from, to, nil
+}
+
+// parseDateBounds parses the optional from and to query parameters like
+// parseDateRange, but allows to without from
+func parseDateBounds(c *gin.Context) (*time.Time, *time.Time, error) {
+	from, _, err := parseTimeParam(c.Query("from"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("from must be an RFC3339 timestamp or YYYY-MM-DD date")
+	}
+
+	to, dateOnly, err := parseTimeParam(c.Query("to"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("to must be an RFC3339 timestamp or YYYY-MM-DD date")
+	}
+	if to != nil && dateOnly {
+		endOfDay := to.Add(24 * time.Hour)
+		to = &endOfDay
+	}
+
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, nil, fmt.Errorf("from must be before to")
+	}
+
+	return from, to, nil
+}
+
+// parseTimeParam parses an RFC3339 timestamp or a YYYY-MM-DD date
+func parseTimeParam(value string) (*time.Time, bool, error) {
+	if value == "" {
+		return nil, false, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, false, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, false, fmt.Errorf("expected RFC3339 timestamp or YYYY-MM-DD date")
+	}
+	return &t, true, nil
+}
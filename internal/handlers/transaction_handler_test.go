@@ -2,24 +2,27 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
+	"transaction-api/internal/middleware"
 	"transaction-api/internal/models"
+	"transaction-api/internal/repository"
 	"transaction-api/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
-	"gorm.io/gorm"
-	"github.com/glebarez/sqlite"
 )
 
 type TransactionHandlerTestSuite struct {
 	suite.Suite
-	db      *gorm.DB
+	repo    *repository.MemoryTransactionRepository
 	service *services.TransactionService
 	handler *TransactionHandler
 	router  *gin.Engine
@@ -29,16 +32,10 @@ func (suite *TransactionHandlerTestSuite) SetupTest() {
 	// Set Gin to test mode
 	gin.SetMode(gin.TestMode)
 
-	// Use SQLite in-memory database for testing
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	suite.Require().NoError(err)
-
-	// Auto migrate the schema
-	err = db.AutoMigrate(&models.Transaction{})
-	suite.Require().NoError(err)
-
-	suite.db = db
-	suite.service = services.NewTransactionService(db)
+	// Handlers are tested against the in-memory repository; the database
+	// queries are covered by the repository tests
+	suite.repo = repository.NewMemoryTransactionRepository()
+	suite.service = services.NewTransactionServiceWithRepository(suite.repo)
 	suite.handler = NewTransactionHandler(suite.service)
 
 	// Setup router
@@ -49,17 +46,14 @@ func (suite *TransactionHandlerTestSuite) SetupTest() {
 	router.PUT("/transactions/:id", suite.handler.UpdateTransaction)
 	router.DELETE("/transactions/:id", suite.handler.DeleteTransaction)
 	router.GET("/dashboard/summary", suite.handler.GetDashboardSummary)
-	router.GET("/health", suite.handler.HealthCheck)
+	router.GET("/dashboard/distribution", suite.handler.GetAmountDistribution)
+	router.GET("/dashboard/top-users", suite.handler.GetTopUsers)
+	router.GET("/dashboard/failures", suite.handler.GetFailureBreakdown)
+	router.GET("/users/:id/summary", suite.handler.GetUserSummary)
 
 	suite.router = router
 }
 
-func (suite *TransactionHandlerTestSuite) TearDownTest() {
-	sqlDB, err := suite.db.DB()
-	suite.Require().NoError(err)
-	sqlDB.Close()
-}
-
 func (suite *TransactionHandlerTestSuite) TestCreateTransaction() {
 	// Test valid request
 	reqBody := models.TransactionRequest{
@@ -97,6 +91,28 @@ func (suite *TransactionHandlerTestSuite) TestCreateTransaction() {
 	suite.router.ServeHTTP(w, req)
 
 	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	// Test problem details name the failing fields by their JSON name
+	jsonBody, _ = json.Marshal(map[string]interface{}{"user_id": 1, "amount": -5})
+
+	req, _ = http.NewRequest("POST", "/transactions", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", middleware.ProblemContentType)
+	w = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Equal(suite.T(), middleware.ProblemContentType, w.Header().Get("Content-Type"))
+
+	var problem middleware.ProblemDetails
+	err = json.Unmarshal(w.Body.Bytes(), &problem)
+	assert.NoError(suite.T(), err)
+	if assert.Len(suite.T(), problem.Errors, 1) {
+		assert.Equal(suite.T(), "amount", problem.Errors[0].Field)
+		assert.Equal(suite.T(), "gt", problem.Errors[0].Rule)
+		assert.Equal(suite.T(), "0", problem.Errors[0].Param)
+	}
 }
 
 func (suite *TransactionHandlerTestSuite) TestGetTransactionByID() {
@@ -106,7 +122,7 @@ func (suite *TransactionHandlerTestSuite) TestGetTransactionByID() {
 		Amount: 100.50,
 		Status: models.StatusSuccess,
 	}
-	err := suite.db.Create(transaction).Error
+	err := suite.repo.Create(context.Background(), transaction)
 	suite.Require().NoError(err)
 
 	// Test getting existing transaction
@@ -148,7 +164,7 @@ func (suite *TransactionHandlerTestSuite) TestGetTransactions() {
 	}
 
 	for i := range transactions {
-		err := suite.db.Create(&transactions[i]).Error
+		err := suite.repo.Create(context.Background(), &transactions[i])
 		suite.Require().NoError(err)
 	}
 
@@ -194,7 +210,7 @@ func (suite *TransactionHandlerTestSuite) TestUpdateTransaction() {
 		Amount: 100.50,
 		Status: models.StatusPending,
 	}
-	err := suite.db.Create(transaction).Error
+	err := suite.repo.Create(context.Background(), transaction)
 	suite.Require().NoError(err)
 
 	// Test updating existing transaction
@@ -238,6 +254,54 @@ func (suite *TransactionHandlerTestSuite) TestUpdateTransaction() {
 	suite.router.ServeHTTP(w, req)
 
 	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	// Test failed status without failure code
+	invalidUpdateReq = models.TransactionUpdateRequest{
+		Status: models.StatusFailed,
+	}
+	jsonBody, _ = json.Marshal(invalidUpdateReq)
+
+	req, _ = http.NewRequest("PUT", fmt.Sprintf("/transactions/%d", transaction.ID), bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	// Test failed status with unknown failure code
+	invalidUpdateReq = models.TransactionUpdateRequest{
+		Status:      models.StatusFailed,
+		FailureCode: "unknown_code",
+	}
+	jsonBody, _ = json.Marshal(invalidUpdateReq)
+
+	req, _ = http.NewRequest("PUT", fmt.Sprintf("/transactions/%d", transaction.ID), bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	// Test failed status with catalog failure code
+	failUpdateReq := models.TransactionUpdateRequest{
+		Status:      models.StatusFailed,
+		FailureCode: "declined",
+	}
+	jsonBody, _ = json.Marshal(failUpdateReq)
+
+	req, _ = http.NewRequest("PUT", fmt.Sprintf("/transactions/%d", transaction.ID), bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "declined", response.FailureCode)
 }
 
 func (suite *TransactionHandlerTestSuite) TestDeleteTransaction() {
@@ -247,7 +311,7 @@ func (suite *TransactionHandlerTestSuite) TestDeleteTransaction() {
 		Amount: 100.50,
 		Status: models.StatusPending,
 	}
-	err := suite.db.Create(transaction).Error
+	err := suite.repo.Create(context.Background(), transaction)
 	suite.Require().NoError(err)
 
 	// Test deleting existing transaction
@@ -284,7 +348,7 @@ func (suite *TransactionHandlerTestSuite) TestGetDashboardSummary() {
 	}
 
 	for i := range transactions {
-		err := suite.db.Create(&transactions[i]).Error
+		err := suite.repo.Create(context.Background(), &transactions[i])
 		suite.Require().NoError(err)
 	}
 
@@ -301,21 +365,297 @@ func (suite *TransactionHandlerTestSuite) TestGetDashboardSummary() {
 	assert.Equal(suite.T(), int64(3), response.TotalTransactions)
 	assert.NotNil(suite.T(), response.StatusDistribution)
 	assert.Equal(suite.T(), 3, len(response.RecentTransactions))
+	assert.Nil(suite.T(), response.Comparison)
+
+	// Test comparison with custom range
+	req, _ = http.NewRequest("GET", "/dashboard/summary?from=2020-01-01&to=2020-01-31&compare=previous_period", nil)
+	w = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	response = models.DashboardSummary{}
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(0), response.TotalTransactions)
+	suite.Require().NotNil(response.Comparison)
+	assert.Equal(suite.T(), models.ComparePreviousPeriod, response.Comparison.Mode)
+
+	// Test invalid compare mode
+	req, _ = http.NewRequest("GET", "/dashboard/summary?compare=last_week", nil)
+	w = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	// Test invalid date range
+	req, _ = http.NewRequest("GET", "/dashboard/summary?from=2020-02-01&to=2020-01-01", nil)
+	w = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
 }
 
-func (suite *TransactionHandlerTestSuite) TestHealthCheck() {
-	req, _ := http.NewRequest("GET", "/health", nil)
+func (suite *TransactionHandlerTestSuite) TestGetAmountDistribution() {
+	// Create test transactions
+	transactions := []models.Transaction{
+		{UserID: 1, Amount: 10.0, Status: models.StatusSuccess},
+		{UserID: 1, Amount: 20.0, Status: models.StatusSuccess},
+		{UserID: 2, Amount: 30.0, Status: models.StatusFailed},
+	}
+
+	for i := range transactions {
+		err := suite.repo.Create(context.Background(), &transactions[i])
+		suite.Require().NoError(err)
+	}
+
+	req, _ := http.NewRequest("GET", "/dashboard/distribution?status=success&buckets=2", nil)
 	w := httptest.NewRecorder()
 
 	suite.router.ServeHTTP(w, req)
 
 	assert.Equal(suite.T(), http.StatusOK, w.Code)
 
-	var response map[string]interface{}
+	var response models.AmountDistribution
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(suite.T(), err)
-	assert.Equal(suite.T(), "healthy", response["status"])
-	assert.Equal(suite.T(), "transaction-api", response["service"])
+	assert.Equal(suite.T(), int64(2), response.Count)
+	assert.Equal(suite.T(), models.ScaleLinear, response.Scale)
+	assert.Equal(suite.T(), 2, len(response.Buckets))
+	assert.Equal(suite.T(), 10.0, response.Percentiles.P50)
+
+	// Test invalid scale
+	req, _ = http.NewRequest("GET", "/dashboard/distribution?scale=cubic", nil)
+	w = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	// Test non-positive min with logarithmic scale
+	req, _ = http.NewRequest("GET", "/dashboard/distribution?scale=log&min=0", nil)
+	w = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *TransactionHandlerTestSuite) TestGetTopUsers() {
+	// Create test transactions
+	transactions := []models.Transaction{
+		{UserID: 1, Amount: 100.0, Status: models.StatusSuccess},
+		{UserID: 2, Amount: 300.0, Status: models.StatusSuccess},
+		{UserID: 2, Amount: 50.0, Status: models.StatusFailed},
+	}
+
+	for i := range transactions {
+		err := suite.repo.Create(context.Background(), &transactions[i])
+		suite.Require().NoError(err)
+	}
+
+	req, _ := http.NewRequest("GET", "/dashboard/top-users?by=amount&status=success&limit=5", nil)
+	w := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response models.TopUsersResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(suite.T(), err)
+	suite.Require().Equal(2, len(response.Users))
+	assert.Equal(suite.T(), uint(2), response.Users[0].UserID)
+	assert.Equal(suite.T(), 0.75, response.Users[0].Share)
+
+	// Test invalid ranking metric
+	req, _ = http.NewRequest("GET", "/dashboard/top-users?by=latency", nil)
+	w = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *TransactionHandlerTestSuite) TestGetFailureBreakdown() {
+	// Create test transactions
+	transactions := []models.Transaction{
+		{UserID: 1, Amount: 100.0, Status: models.StatusSuccess},
+		{UserID: 2, Amount: 200.0, Status: models.StatusFailed, FailureCode: "timeout"},
+	}
+
+	for i := range transactions {
+		err := suite.repo.Create(context.Background(), &transactions[i])
+		suite.Require().NoError(err)
+	}
+
+	req, _ := http.NewRequest("GET", "/dashboard/failures?interval=hour", nil)
+	w := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response models.FailureBreakdown
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), models.IntervalHour, response.Interval)
+	assert.Equal(suite.T(), int64(2), response.TotalTransactions)
+	assert.Equal(suite.T(), int64(1), response.ByCode["timeout"])
+	assert.Equal(suite.T(), 0.5, response.FailureRate)
+
+	// Test from defaults from to and the interval
+	for query, from := range map[string]time.Time{
+		"to=2024-03-10":                           time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+		"to=2024-03-10T12:00:00Z&interval=hour":   time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC),
+		"to=2024-03-10T12:00:00Z&from=2024-03-01": time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		req, _ = http.NewRequest("GET", "/dashboard/failures?"+query, nil)
+		w = httptest.NewRecorder()
+
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusOK, w.Code, query)
+		response = models.FailureBreakdown{}
+		assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(suite.T(), from.Equal(response.From), query)
+	}
+
+	// Test invalid interval
+	req, _ = http.NewRequest("GET", "/dashboard/failures?interval=minute", nil)
+	w = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *TransactionHandlerTestSuite) TestGetUserSummary() {
+	// Create test transactions
+	transactions := []models.Transaction{
+		{UserID: 1, Amount: 100.0, Status: models.StatusSuccess},
+		{UserID: 1, Amount: 200.0, Status: models.StatusFailed},
+		{UserID: 2, Amount: 300.0, Status: models.StatusSuccess},
+	}
+
+	for i := range transactions {
+		err := suite.repo.Create(context.Background(), &transactions[i])
+		suite.Require().NoError(err)
+	}
+
+	req, _ := http.NewRequest("GET", "/users/1/summary", nil)
+	w := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response models.UserSummary
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), uint(1), response.UserID)
+	assert.Equal(suite.T(), int64(2), response.TransactionCount)
+	assert.Equal(suite.T(), 0.5, response.SuccessRate)
+	assert.Equal(suite.T(), 100.0, response.TotalAmount)
+
+	// Test invalid ID
+	req, _ = http.NewRequest("GET", "/users/invalid/summary", nil)
+	w = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *TransactionHandlerTestSuite) TestServiceTimeoutAndCancellation() {
+	// Queries exceeding their timeout respond with 504
+	suite.service.SetTimeouts(services.Timeouts{Analytics: time.Nanosecond})
+
+	req, _ := http.NewRequest("GET", "/dashboard/summary", nil)
+	w := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusGatewayTimeout, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "timeout")
+
+	// Requests cancelled by the client respond with 499
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ = http.NewRequestWithContext(ctx, "GET", "/transactions", nil)
+	w = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), middleware.StatusClientClosedRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "client_closed_request")
+}
+
+func (suite *TransactionHandlerTestSuite) TestLocalizedErrors() {
+	// Test domain errors follow Accept-Language
+	req, _ := http.NewRequest("GET", "/transactions/999", nil)
+	req.Header.Set("Accept-Language", "id-ID,id;q=0.9")
+	w := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	assert.Equal(suite.T(), "id", w.Header().Get("Content-Language"))
+
+	var response middleware.ErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "not_found", response.Error)
+	assert.Equal(suite.T(), "transaksi tidak ditemukan", response.Message)
+
+	// Test handler messages are translated too, with English as the fallback
+	for language, message := range map[string]string{
+		"id": "ID transaksi tidak valid",
+		"fr": "Invalid transaction ID",
+		"":   "Invalid transaction ID",
+	} {
+		req, _ = http.NewRequest("GET", "/transactions/invalid", nil)
+		req.Header.Set("Accept-Language", language)
+		w = httptest.NewRecorder()
+
+		suite.router.ServeHTTP(w, req)
+
+		err = json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), message, response.Message, language)
+	}
+}
+
+func (suite *TransactionHandlerTestSuite) TestInternalErrorsAreNotLeaked() {
+	// Queries fail with a database error
+	handler := NewTransactionHandler(services.NewTransactionServiceWithRepository(failingRepository{suite.repo}))
+	router := gin.New()
+	router.GET("/transactions", handler.GetTransactions)
+
+	req, _ := http.NewRequest("GET", "/transactions", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusInternalServerError, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "internal_server_error")
+	assert.NotContains(suite.T(), w.Body.String(), "no such table")
+}
+
+// failingRepository fails to list transactions as a broken database would
+type failingRepository struct {
+	repository.TransactionRepository
+}
+
+func (r failingRepository) WithPrimary() repository.TransactionRepository {
+	return r
+}
+
+func (failingRepository) List(context.Context, repository.TransactionFilter, int, int) ([]models.Transaction, int64, error) {
+	return nil, 0, errors.New("no such table: transactions")
 }
 
 func TestTransactionHandlerTestSuite(t *testing.T) {
@@ -0,0 +1,153 @@
+// Package i18n provides the Indonesian and English message catalogs used for
+// error responses. Messages are keyed by their English text, with {0}, {1}...
+// placeholders for parameters, so English needs no catalog and is the
+// fallback for any message without a translation.
+package i18n
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	id_translations "github.com/go-playground/validator/v10/translations/id"
+)
+
+var (
+	universal = ut.New(en.New(), en.New(), id.New())
+
+	// English is the fallback translator
+	English = mustTranslator("en")
+	// Indonesian translates the messages in indonesianMessages
+	Indonesian = mustTranslator("id")
+)
+
+func init() {
+	for key, text := range indonesianMessages {
+		if err := Indonesian.Add(key, text, false); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func mustTranslator(locale string) ut.Translator {
+	trans, found := universal.GetTranslator(locale)
+	if !found {
+		panic("i18n: missing translator for " + locale)
+	}
+	return trans
+}
+
+// FromAcceptLanguage returns the translator best matching an Accept-Language
+// header, or English when no supported language is acceptable
+func FromAcceptLanguage(header string) ut.Translator {
+	trans, _ := universal.FindTranslator(acceptedLanguages(header)...)
+	return trans
+}
+
+// acceptedLanguages lists the languages of an Accept-Language header by
+// preference. Regional tags are followed by their base language.
+func acceptedLanguages(header string) []string {
+	type language struct {
+		tag     string
+		quality float64
+	}
+
+	var languages []language
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil || parsed <= 0 {
+				continue
+			}
+			quality = parsed
+		}
+		languages = append(languages, language{tag: tag, quality: quality})
+	}
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+
+	tags := make([]string, 0, len(languages)*2)
+	for _, lang := range languages {
+		tag := strings.ReplaceAll(lang.tag, "-", "_")
+		tags = append(tags, tag)
+		if base, _, regional := strings.Cut(tag, "_"); regional {
+			tags = append(tags, base)
+		}
+	}
+	return tags
+}
+
+// T translates a message, falling back to the English text of key
+func T(trans ut.Translator, key string, params ...string) string {
+	if trans != nil {
+		if text, err := trans.T(key, params...); err == nil {
+			return text
+		}
+	}
+	return Format(key, params...)
+}
+
+// Format fills the placeholders of an English message
+func Format(key string, params ...string) string {
+	for i, param := range params {
+		key = strings.ReplaceAll(key, "{"+strconv.Itoa(i)+"}", param)
+	}
+	return key
+}
+
+var (
+	validate     *validator.Validate
+	validateOnce sync.Once
+)
+
+// Validator returns the shared validator. It names fields by their JSON name
+// and has messages for every supported language.
+func Validator() *validator.Validate {
+	validateOnce.Do(func() {
+		validate = validator.New()
+		validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+
+		if err := en_translations.RegisterDefaultTranslations(validate, English); err != nil {
+			panic(err)
+		}
+		if err := id_translations.RegisterDefaultTranslations(validate, Indonesian); err != nil {
+			panic(err)
+		}
+	})
+	return validate
+}
+
+// ValidationMessage describes a failed validation rule in the language of
+// trans. Rules without a translation fall back to English, then to a generic
+// message.
+func ValidationMessage(trans ut.Translator, fe validator.FieldError) string {
+	for _, t := range []ut.Translator{trans, English} {
+		if t == nil {
+			continue
+		}
+		if message := fe.Translate(t); message != fe.Error() {
+			return message
+		}
+	}
+	return T(trans, "{0} failed the {1} rule", fe.Field(), fe.Tag())
+}
@@ -0,0 +1,71 @@
+package i18n
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		locale string
+	}{
+		{"", "en"},
+		{"id", "id"},
+		{"id-ID", "id"},
+		{"en-US,en;q=0.9", "en"},
+		{"fr-FR,id;q=0.8,en;q=0.5", "id"},
+		{"en;q=0.4, id;q=0.7", "id"},
+		{"id;q=0, en", "en"},
+		{"fr, de", "en"},
+		{"*", "en"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.locale, FromAcceptLanguage(tt.header).Locale(), tt.header)
+	}
+}
+
+func TestT(t *testing.T) {
+	assert.Equal(t, "transaksi tidak ditemukan", T(Indonesian, "transaction not found"))
+	assert.Equal(t, "failure_code tidak dikenal: cosmic_rays", T(Indonesian, "unknown failure_code: {0}", "cosmic_rays"))
+
+	// English and untranslated messages use the key itself
+	assert.Equal(t, "unknown failure_code: cosmic_rays", T(English, "unknown failure_code: {0}", "cosmic_rays"))
+	assert.Equal(t, "Something new", T(Indonesian, "Something new"))
+	assert.Equal(t, "Something new", T(nil, "Something new"))
+}
+
+func TestIndonesianMessagesKeepPlaceholders(t *testing.T) {
+	placeholders := regexp.MustCompile(`\{\d+\}`)
+	for key, text := range indonesianMessages {
+		assert.ElementsMatch(t, placeholders.FindAllString(key, -1), placeholders.FindAllString(text, -1), key)
+	}
+}
+
+func TestValidationMessage(t *testing.T) {
+	type request struct {
+		Amount      float64 `json:"amount" validate:"required,gt=0"`
+		Status      string  `json:"status" validate:"required"`
+		FailureCode string  `json:"failure_code,omitempty" validate:"required_if=Status failed"`
+	}
+
+	err := Validator().Struct(&request{Amount: -1, Status: "failed"})
+	require.Error(t, err)
+
+	var errs validator.ValidationErrors
+	require.True(t, errors.As(err, &errs))
+	require.Len(t, errs, 2)
+
+	assert.Equal(t, "amount must be greater than 0", ValidationMessage(English, errs[0]))
+	assert.Equal(t, "amount harus lebih besar dari 0", ValidationMessage(Indonesian, errs[0]))
+
+	// Rules without a translation fall back to English, then to a generic message
+	message := ValidationMessage(Indonesian, errs[1])
+	assert.Contains(t, message, "failure_code")
+}
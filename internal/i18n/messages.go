@@ -0,0 +1,44 @@
+package i18n
+
+// indonesianMessages translates the English messages returned to clients
+var indonesianMessages = map[string]string{
+	// Error responses
+	"Request validation failed":                "Validasi permintaan gagal",
+	"The request took too long to process":     "Permintaan terlalu lama untuk diproses",
+	"The request was cancelled":                "Permintaan dibatalkan",
+	"An unexpected error occurred":             "Terjadi kesalahan yang tidak terduga",
+	"{0} failed the {1} rule":                  "{0} tidak memenuhi aturan {1}",
+	"{0} must be of type {1}":                  "{0} harus bertipe {1}",
+	"Too many requests, try again later":       "Terlalu banyak permintaan, coba lagi nanti",
+	"Daily quota exceeded, try again tomorrow": "Kuota harian terlampaui, coba lagi besok",
+
+	// Request parameters
+	"Invalid transaction ID":                                 "ID transaksi tidak valid",
+	"Invalid user ID":                                        "ID pengguna tidak valid",
+	"Status must be one of: pending, success, failed":        "Status harus salah satu dari: pending, success, failed",
+	"Top users must be a non-negative integer":               "Top users harus berupa bilangan bulat non-negatif",
+	"Compare must be one of: previous_period, previous_year": "Compare harus salah satu dari: previous_period, previous_year",
+	"Scale must be one of: linear, log":                      "Scale harus salah satu dari: linear, log",
+	"Min must not be greater than max":                       "Min tidak boleh lebih besar dari max",
+	"Min must be positive for logarithmic scale":             "Min harus positif untuk skala logaritmik",
+	"By must be one of: amount, count":                       "By harus salah satu dari: amount, count",
+	"Interval must be one of: hour, day":                     "Interval harus salah satu dari: hour, day",
+	"from must be an RFC3339 timestamp or YYYY-MM-DD date":   "from harus berupa timestamp RFC3339 atau tanggal YYYY-MM-DD",
+	"to must be an RFC3339 timestamp or YYYY-MM-DD date":     "to harus berupa timestamp RFC3339 atau tanggal YYYY-MM-DD",
+	"from is required when to is set":                        "from wajib diisi jika to diisi",
+	"from must be before to":                                 "from harus sebelum to",
+
+	// Domain errors
+	"transaction not found":      "transaksi tidak ditemukan",
+	"transaction already exists": "transaksi sudah ada",
+	"failure_code and failure_message are only allowed when status is failed": "failure_code dan failure_message hanya diperbolehkan jika status adalah failed",
+	"failure_code is required when status is failed":                          "failure_code wajib diisi jika status adalah failed",
+	"unknown failure_code: {0}":                                               "failure_code tidak dikenal: {0}",
+	"invalid compare mode: {0}":                                               "mode perbandingan tidak valid: {0}",
+	"invalid amount range: min {0} is greater than max {1}":                   "rentang jumlah tidak valid: min {0} lebih besar dari max {1}",
+	"invalid amount range: logarithmic scale requires a positive min":         "rentang jumlah tidak valid: skala logaritmik membutuhkan min positif",
+	"invalid bucket scale: {0}":                                               "skala bucket tidak valid: {0}",
+	"invalid top users metric: {0}":                                           "metrik top users tidak valid: {0}",
+	"invalid interval: {0}":                                                   "interval tidak valid: {0}",
+	"invalid audit action: {0}":                                               "aksi audit tidak valid: {0}",
+}
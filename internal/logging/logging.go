@@ -0,0 +1,47 @@
+// Package logging carries a request-scoped logger and the request ID in a
+// context, so log lines written anywhere during a request can be correlated.
+package logging
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+type contextKey int
+
+const (
+	loggerKey contextKey = iota
+	requestIDKey
+)
+
+// WithLogger returns a copy of ctx carrying entry
+func WithLogger(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, loggerKey, entry)
+}
+
+// WithFields returns a copy of ctx whose logger carries fields in addition
+// to the fields already in ctx
+func WithFields(ctx context.Context, fields logrus.Fields) context.Context {
+	return WithLogger(ctx, FromContext(ctx).WithFields(fields))
+}
+
+// FromContext returns the logger carried by ctx, or the standard logger when
+// there is none. The entry is bound to ctx so hooks can read its trace.
+func FromContext(ctx context.Context) *logrus.Entry {
+	if entry, ok := ctx.Value(loggerKey).(*logrus.Entry); ok {
+		return entry.WithContext(ctx)
+	}
+	return logrus.WithContext(ctx)
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, or ""
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
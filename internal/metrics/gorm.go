@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const queryStartKey = "metrics:query_start"
+
+// GormPlugin records the duration of every GORM query in the
+// db_query_duration_seconds histogram
+type GormPlugin struct {
+	metrics *Metrics
+}
+
+// NewGormPlugin returns a GORM plugin recording query durations into m.
+// Register it with db.Use.
+func NewGormPlugin(m *Metrics) *GormPlugin {
+	return &GormPlugin{metrics: m}
+}
+
+// Name implements gorm.Plugin
+func (p *GormPlugin) Name() string {
+	return "metrics"
+}
+
+// Initialize implements gorm.Plugin by wrapping each callback chain with
+// timing callbacks
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	register := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", callbacks.Create().Before("gorm:create").Register, callbacks.Create().After("gorm:create").Register},
+		{"query", callbacks.Query().Before("gorm:query").Register, callbacks.Query().After("gorm:query").Register},
+		{"update", callbacks.Update().Before("gorm:update").Register, callbacks.Update().After("gorm:update").Register},
+		{"delete", callbacks.Delete().Before("gorm:delete").Register, callbacks.Delete().After("gorm:delete").Register},
+		{"row", callbacks.Row().Before("gorm:row").Register, callbacks.Row().After("gorm:row").Register},
+		{"raw", callbacks.Raw().Before("gorm:raw").Register, callbacks.Raw().After("gorm:raw").Register},
+	}
+
+	for _, r := range register {
+		if err := r.before("metrics:before_"+r.operation, startQuery); err != nil {
+			return err
+		}
+		if err := r.after("metrics:after_"+r.operation, p.finishQuery(r.operation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startQuery stores the query start time on the statement
+func startQuery(db *gorm.DB) {
+	db.InstanceSet(queryStartKey, time.Now())
+}
+
+// finishQuery returns a callback recording the duration of operation
+func (p *GormPlugin) finishQuery(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(queryStartKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		p.metrics.observeQuery(operation, table, time.Since(start))
+	}
+}
@@ -0,0 +1,147 @@
+// Package metrics collects Prometheus metrics for HTTP requests, transactions
+// and database queries, and serves them in the Prometheus text format.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"transaction-api/internal/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "transaction_api"
+
+// Metrics holds the application collectors and the registry serving them.
+// A nil *Metrics is valid and records nothing.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests        *prometheus.CounterVec
+	httpRequestDuration *prometheus.HistogramVec
+
+	transactionsCreated    *prometheus.CounterVec
+	transactionTransitions *prometheus.CounterVec
+	transactionAmount      *prometheus.SummaryVec
+
+	dbQueryDuration *prometheus.HistogramVec
+}
+
+// New returns metrics registered on a new registry, along with the Go
+// runtime and process collectors
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route template, method and status code.",
+		}, []string{"route", "method", "status"}),
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route template, method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+
+		transactionsCreated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "transactions_created_total",
+			Help:      "Transactions created by initial status.",
+		}, []string{"status"}),
+		transactionTransitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "transaction_transitions_total",
+			Help:      "Transaction status transitions by previous and new status.",
+		}, []string{"from", "to"}),
+		transactionAmount: prometheus.NewSummaryVec(prometheus.SummaryOpts{
+			Namespace:  namespace,
+			Name:       "transaction_amount",
+			Help:       "Transaction amounts by status, observed on creation and on every transition.",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+			MaxAge:     10 * time.Minute,
+		}, []string{"status"}),
+
+		dbQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Database query latency by operation and table.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"operation", "table"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpRequestDuration,
+		m.transactionsCreated,
+		m.transactionTransitions,
+		m.transactionAmount,
+		m.dbQueryDuration,
+	)
+
+	return m
+}
+
+// Handler serves the registered metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Registry returns the registry the metrics are registered on
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// RegisterDBStats exports the connection pool statistics of db as gauges
+// labelled with name
+func (m *Metrics) RegisterDBStats(db *sql.DB, name string) error {
+	return m.registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveRequest records a served HTTP request. route is the route template
+// such as /api/v1/transactions/:id, never the raw path, to bound cardinality.
+func (m *Metrics) ObserveRequest(route, method string, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	code := strconv.Itoa(status)
+	m.httpRequests.WithLabelValues(route, method, code).Inc()
+	m.httpRequestDuration.WithLabelValues(route, method, code).Observe(duration.Seconds())
+}
+
+// TransactionCreated records a created transaction
+func (m *Metrics) TransactionCreated(transaction *models.Transaction) {
+	if m == nil {
+		return
+	}
+	status := string(transaction.Status)
+	m.transactionsCreated.WithLabelValues(status).Inc()
+	m.transactionAmount.WithLabelValues(status).Observe(transaction.Amount)
+}
+
+// TransactionTransitioned records a transaction moving from one status to
+// the transaction's current status
+func (m *Metrics) TransactionTransitioned(from models.TransactionStatus, transaction *models.Transaction) {
+	if m == nil {
+		return
+	}
+	status := string(transaction.Status)
+	m.transactionTransitions.WithLabelValues(string(from), status).Inc()
+	m.transactionAmount.WithLabelValues(status).Observe(transaction.Amount)
+}
+
+// observeQuery records a database query
+func (m *Metrics) observeQuery(operation, table string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.dbQueryDuration.WithLabelValues(operation, table).Observe(duration.Seconds())
+}
@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"transaction-api/internal/models"
+
+	"github.com/glebarez/sqlite"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestObserveRequest(t *testing.T) {
+	m := New()
+
+	m.ObserveRequest("/api/v1/transactions/:id", "GET", 200, 20*time.Millisecond)
+	m.ObserveRequest("/api/v1/transactions/:id", "GET", 200, 30*time.Millisecond)
+	m.ObserveRequest("/api/v1/transactions/:id", "GET", 404, time.Millisecond)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.httpRequests.WithLabelValues("/api/v1/transactions/:id", "GET", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.httpRequests.WithLabelValues("/api/v1/transactions/:id", "GET", "404")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.httpRequestDuration))
+}
+
+func TestTransactionMetrics(t *testing.T) {
+	m := New()
+
+	transaction := &models.Transaction{UserID: 1, Amount: 100, Status: models.StatusPending}
+	m.TransactionCreated(transaction)
+	transaction.Status = models.StatusSuccess
+	m.TransactionTransitioned(models.StatusPending, transaction)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.transactionsCreated.WithLabelValues("pending")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.transactionTransitions.WithLabelValues("pending", "success")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.transactionAmount))
+}
+
+func TestNilMetrics(t *testing.T) {
+	var m *Metrics
+
+	assert.NotPanics(t, func() {
+		m.ObserveRequest("/health", "GET", 200, time.Millisecond)
+		m.TransactionCreated(&models.Transaction{})
+		m.TransactionTransitioned(models.StatusPending, &models.Transaction{})
+		m.observeQuery("query", "transactions", time.Millisecond)
+	})
+}
+
+func TestGormPluginAndDBStats(t *testing.T) {
+	m := New()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(NewGormPlugin(m)))
+	require.NoError(t, db.AutoMigrate(&models.Transaction{}, &models.AuditEvent{}))
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	require.NoError(t, m.RegisterDBStats(sqlDB, "test"))
+
+	transaction := models.Transaction{UserID: 1, Amount: 10, Status: models.StatusPending}
+	require.NoError(t, db.Create(&transaction).Error)
+	require.NoError(t, db.First(&models.Transaction{}, transaction.ID).Error)
+	require.NoError(t, db.Model(&transaction).Update("status", models.StatusSuccess).Error)
+
+	families, err := m.Registry().Gather()
+	require.NoError(t, err)
+
+	queries := make(map[string]uint64)
+	var poolStats bool
+	for _, family := range families {
+		switch family.GetName() {
+		case "transaction_api_db_query_duration_seconds":
+			for _, metric := range family.GetMetric() {
+				labels := make(map[string]string)
+				for _, label := range metric.GetLabel() {
+					labels[label.GetName()] = label.GetValue()
+				}
+				if labels["table"] == "transactions" {
+					queries[labels["operation"]] += metric.GetHistogram().GetSampleCount()
+				}
+			}
+		case "go_sql_open_connections":
+			poolStats = len(family.GetMetric()) == 1 && family.GetMetric()[0].GetLabel()[0].GetValue() == "test"
+		}
+	}
+
+	assert.Equal(t, uint64(1), queries["create"])
+	assert.Equal(t, uint64(1), queries["query"])
+	assert.Equal(t, uint64(1), queries["update"])
+	assert.True(t, poolStats)
+}
+
+func TestHandler(t *testing.T) {
+	m := New()
+	m.ObserveRequest("/health", "GET", 200, time.Millisecond)
+
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, 200, recorder.Code)
+	body, err := io.ReadAll(recorder.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `transaction_api_http_requests_total{method="GET",route="/health",status="200"} 1`)
+	assert.Contains(t, string(body), "go_goroutines")
+}
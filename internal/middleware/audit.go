@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"transaction-api/internal/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditActor stores who is making the request in the request context, so
+// the changes it makes are attributed in the audit log. Unauthenticated
+// callers are recorded as anonymous, and the IP is the connection address or
+// the one forwarded by a trusted proxy. It must run after RequestID.
+func AuditActor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := c.GetString(UserKey)
+		if actor == "" {
+			actor = audit.AnonymousActor
+		}
+
+		ctx := audit.WithActor(c.Request.Context(), audit.Actor{
+			ID:        actor,
+			IP:        c.ClientIP(),
+			RequestID: c.GetString(RequestIDKey),
+		})
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
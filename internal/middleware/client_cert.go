@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"crypto/x509"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientCertificateKey is the gin context key holding the verified client
+// certificate of mutual TLS requests
+const ClientCertificateKey = "client_certificate"
+
+// ClientCertificate authenticates mutual TLS requests: the subject of a
+// verified client certificate, such as "CN=billing,O=Acme", becomes the
+// user. It must run before the middleware that identifies the caller.
+func ClientCertificate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if certificate := verifiedClientCertificate(c); certificate != nil {
+			c.Set(ClientCertificateKey, certificate)
+			c.Set(UserKey, certificate.Subject.String())
+		}
+
+		c.Next()
+	}
+}
+
+// verifiedClientCertificate returns the leaf of the client's verified chain.
+// Certificates that were presented but not verified are ignored.
+func verifiedClientCertificate(c *gin.Context) *x509.Certificate {
+	state := c.Request.TLS
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return state.VerifiedChains[0][0]
+}
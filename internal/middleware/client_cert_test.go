@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"transaction-api/internal/audit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientCertificate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(ClientCertificate())
+	router.Use(RequestID())
+	router.Use(AuditActor())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, audit.ActorFromContext(c.Request.Context()).ID)
+	})
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "billing", Organization: []string{"Acme"}}}
+	request := func(state *tls.ConnectionState) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.TLS = state
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	// The verified certificate subject is the caller
+	assert.Equal(t, "CN=billing,O=Acme", request(&tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{cert},
+		VerifiedChains:   [][]*x509.Certificate{{cert}},
+	}))
+
+	// Unverified certificates and plain requests are anonymous
+	assert.Equal(t, audit.AnonymousActor, request(&tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}))
+	assert.Equal(t, audit.AnonymousActor, request(nil))
+}
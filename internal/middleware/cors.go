@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"transaction-api/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// corsPolicy is a CORSConfig prepared for matching
+type corsPolicy struct {
+	anyOrigin bool
+	origins   map[string]bool
+	// wildcards hold the scheme and the domain suffix, with its port, of
+	// wildcard subdomain origins
+	wildcards [][2]string
+
+	methods          map[string]bool
+	allowMethods     string
+	headers          map[string]bool
+	allowHeaders     string
+	exposeHeaders    string
+	allowCredentials bool
+	maxAge           string
+}
+
+func newCORSPolicy(cfg config.CORSConfig) *corsPolicy {
+	p := &corsPolicy{
+		origins:          make(map[string]bool),
+		methods:          make(map[string]bool),
+		headers:          make(map[string]bool),
+		exposeHeaders:    strings.Join(cfg.ExposedHeaders, ", "),
+		allowCredentials: cfg.AllowCredentials,
+	}
+
+	for _, origin := range cfg.AllowedOrigins {
+		origin = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(origin)), "/")
+		scheme, host, _ := strings.Cut(origin, "://")
+		switch {
+		case origin == "*":
+			p.anyOrigin = true
+		case strings.HasPrefix(host, "*."):
+			p.wildcards = append(p.wildcards, [2]string{scheme + "://", host[1:]})
+		case origin != "":
+			p.origins[origin] = true
+		}
+	}
+
+	methods := make([]string, 0, len(cfg.AllowedMethods))
+	for _, method := range cfg.AllowedMethods {
+		method = strings.ToUpper(method)
+		if !p.methods[method] {
+			p.methods[method] = true
+			methods = append(methods, method)
+		}
+	}
+	p.allowMethods = strings.Join(methods, ", ")
+
+	for _, header := range cfg.AllowedHeaders {
+		p.headers[http.CanonicalHeaderKey(header)] = true
+	}
+	p.allowHeaders = strings.Join(cfg.AllowedHeaders, ", ")
+
+	if cfg.MaxAge > 0 {
+		p.maxAge = strconv.FormatInt(int64(cfg.MaxAge.Seconds()), 10)
+	}
+	return p
+}
+
+// allowsOrigin reports whether origin matches an allowed origin
+func (p *corsPolicy) allowsOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	if p.anyOrigin || p.origins[origin] {
+		return true
+	}
+	for _, wildcard := range p.wildcards {
+		scheme, suffix := wildcard[0], wildcard[1]
+		if !strings.HasPrefix(origin, scheme) || !strings.HasSuffix(origin, suffix) {
+			continue
+		}
+		if subdomain := origin[len(scheme) : len(origin)-len(suffix)]; validSubdomain(subdomain) {
+			return true
+		}
+	}
+	return false
+}
+
+// validSubdomain reports whether s is one or more DNS labels, so a wildcard
+// cannot match across a port, path or credentials
+func validSubdomain(s string) bool {
+	if s == "" || strings.HasPrefix(s, ".") || strings.HasSuffix(s, ".") || strings.Contains(s, "..") {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// allowsHeaders reports whether every header of a comma separated
+// Access-Control-Request-Headers value is allowed
+func (p *corsPolicy) allowsHeaders(requested string) bool {
+	for _, header := range strings.Split(requested, ",") {
+		if header = strings.TrimSpace(header); header != "" && !p.headers[http.CanonicalHeaderKey(header)] {
+			return false
+		}
+	}
+	return true
+}
+
+// CORS applies the configured cross-origin policy. Preflight requests are
+// answered directly: 204 when the origin, method and headers are allowed and
+// 403 otherwise. Other requests from disallowed origins are served without
+// CORS headers, so browsers withhold the response. Responses vary on Origin.
+func CORS(cfg config.CORSConfig) gin.HandlerFunc {
+	return NewCORSPolicy(cfg).Handler()
+}
+
+// CORSPolicy is a cross-origin policy that can be replaced while serving
+type CORSPolicy struct {
+	current atomic.Pointer[corsPolicy]
+}
+
+// NewCORSPolicy returns the policy of cfg
+func NewCORSPolicy(cfg config.CORSConfig) *CORSPolicy {
+	p := &CORSPolicy{}
+	p.Update(cfg)
+	return p
+}
+
+// Update replaces the policy; requests in flight finish with the old one
+func (p *CORSPolicy) Update(cfg config.CORSConfig) {
+	p.current.Store(newCORSPolicy(cfg))
+}
+
+// Handler applies the current policy as described for CORS
+func (p *CORSPolicy) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		policy := p.current.Load()
+
+		c.Writer.Header().Add("Vary", "Origin")
+
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if preflight {
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if origin == "" {
+			c.Next()
+			return
+		}
+		if !policy.allowsOrigin(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		// The wildcard origin is never combined with credentials; the
+		// configuration rejects it, and browsers would too
+		if policy.anyOrigin {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if policy.allowCredentials && !policy.anyOrigin {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if policy.exposeHeaders != "" {
+				c.Header("Access-Control-Expose-Headers", policy.exposeHeaders)
+			}
+			c.Next()
+			return
+		}
+
+		method := strings.ToUpper(c.GetHeader("Access-Control-Request-Method"))
+		if !policy.methods[method] || !policy.allowsHeaders(c.GetHeader("Access-Control-Request-Headers")) {
+			c.Writer.Header().Del("Access-Control-Allow-Origin")
+			c.Writer.Header().Del("Access-Control-Allow-Credentials")
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		c.Header("Access-Control-Allow-Methods", policy.allowMethods)
+		if policy.allowHeaders != "" {
+			c.Header("Access-Control-Allow-Headers", policy.allowHeaders)
+		}
+		if policy.maxAge != "" {
+			c.Header("Access-Control-Max-Age", policy.maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"transaction-api/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCORSRouter(cfg config.CORSConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(CORS(cfg))
+	router.GET("/transactions", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	router.PATCH("/transactions/:id", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	return router
+}
+
+func corsRequest(router *gin.Engine, method, origin string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/transactions", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func preflight(router *gin.Engine, origin, method, headers string) *httptest.ResponseRecorder {
+	return corsRequest(router, http.MethodOptions, origin, map[string]string{
+		"Access-Control-Request-Method":  method,
+		"Access-Control-Request-Headers": headers,
+	})
+}
+
+var testCORSConfig = config.CORSConfig{
+	AllowedOrigins: []string{"https://app.example.com", "https://*.example.org", "http://localhost:3000/"},
+	AllowedMethods: []string{"GET", "POST", "PATCH"},
+	AllowedHeaders: []string{"Content-Type", "X-Request-ID"},
+	ExposedHeaders: []string{"X-Request-ID", "RateLimit-Remaining"},
+	MaxAge:         10 * time.Minute,
+}
+
+func TestCORSAllowedOrigins(t *testing.T) {
+	router := newCORSRouter(testCORSConfig)
+
+	for _, origin := range []string{
+		"https://app.example.com",
+		"HTTPS://APP.EXAMPLE.COM",
+		"https://api.example.org",
+		"https://a.b.example.org",
+		"http://localhost:3000",
+	} {
+		w := corsRequest(router, http.MethodGet, origin, nil)
+		assert.Equal(t, http.StatusOK, w.Code, origin)
+		assert.Equal(t, origin, w.Header().Get("Access-Control-Allow-Origin"), origin)
+		assert.Equal(t, "X-Request-ID, RateLimit-Remaining", w.Header().Get("Access-Control-Expose-Headers"), origin)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"), origin)
+		assert.Equal(t, []string{"Origin"}, w.Header().Values("Vary"), origin)
+	}
+}
+
+func TestCORSRejectedOrigins(t *testing.T) {
+	router := newCORSRouter(testCORSConfig)
+
+	for _, origin := range []string{
+		"https://evil.com",
+		"http://app.example.com",
+		"https://app.example.com:8443",
+		"https://app.example.com.evil.com",
+		"https://example.org",
+		"https://evil.com/.example.org",
+		"https://user@x.example.org",
+		"http://localhost:3001",
+		"null",
+	} {
+		// Simple requests are served, but without CORS headers
+		w := corsRequest(router, http.MethodGet, origin, nil)
+		assert.Equal(t, http.StatusOK, w.Code, origin)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"), origin)
+		assert.Empty(t, w.Header().Get("Access-Control-Expose-Headers"), origin)
+		assert.Equal(t, []string{"Origin"}, w.Header().Values("Vary"), origin)
+
+		w = preflight(router, origin, "PATCH", "")
+		assert.Equal(t, http.StatusForbidden, w.Code, origin)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"), origin)
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	router := newCORSRouter(testCORSConfig)
+
+	w := preflight(router, "https://app.example.com", "PATCH", "content-type, x-request-id")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, POST, PATCH", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type, X-Request-ID", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	assert.Empty(t, w.Header().Get("Access-Control-Expose-Headers"))
+	assert.Equal(t, []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}, w.Header().Values("Vary"))
+
+	// Disallowed methods and headers are rejected
+	w = preflight(router, "https://app.example.com", "DELETE", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = preflight(router, "https://app.example.com", "GET", "Content-Type, X-Secret")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+
+	// OPTIONS without Access-Control-Request-Method is not a preflight
+	w = corsRequest(router, http.MethodOptions, "https://app.example.com", nil)
+	assert.NotEqual(t, http.StatusNoContent, w.Code)
+}
+
+func TestCORSWithoutOrigin(t *testing.T) {
+	router := newCORSRouter(testCORSConfig)
+
+	w := corsRequest(router, http.MethodGet, "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, []string{"Origin"}, w.Header().Values("Vary"))
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	cfg := testCORSConfig
+	cfg.AllowedOrigins = []string{"*"}
+
+	w := corsRequest(newCORSRouter(cfg), http.MethodGet, "https://anything.test", nil)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+
+	// Any origin never gets credentials, even if the configuration was not
+	// validated
+	cfg.AllowCredentials = true
+	router := newCORSRouter(cfg)
+
+	w = corsRequest(router, http.MethodGet, "https://anything.test", nil)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+
+	w = preflight(router, "https://anything.test", "POST", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORSNoOriginsConfigured(t *testing.T) {
+	cfg := testCORSConfig
+	cfg.AllowedOrigins = nil
+	router := newCORSRouter(cfg)
+
+	w := corsRequest(router, http.MethodGet, "https://app.example.com", nil)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = preflight(router, "https://app.example.com", "GET", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestCORSPolicyUpdate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	policy := NewCORSPolicy(testCORSConfig)
+
+	router := gin.New()
+	router.Use(policy.Handler())
+	router.GET("/transactions", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	w := corsRequest(router, http.MethodGet, "https://new.example.net", nil)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	cfg := testCORSConfig
+	cfg.AllowedOrigins = []string{"https://new.example.net"}
+	policy.Update(cfg)
+
+	w = corsRequest(router, http.MethodGet, "https://new.example.net", nil)
+	assert.Equal(t, "https://new.example.net", w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = corsRequest(router, http.MethodGet, "https://app.example.com", nil)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
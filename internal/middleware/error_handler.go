@@ -1,16 +1,23 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
+	"transaction-api/internal/i18n"
+	"transaction-api/internal/logging"
+	"transaction-api/internal/services"
+
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
 type ErrorResponse struct {
-	Error   string      `json:"error"`
-	Message string      `json:"message,omitempty"`
-	Details interface{} `json:"details,omitempty"`
+	Error     string      `json:"error"`
+	Message   string      `json:"message,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
 }
 
 // ErrorHandler is a middleware that handles panics and errors
@@ -18,40 +25,121 @@ func ErrorHandler() gin.HandlerFunc {
 	return gin.Recovery()
 }
 
-// SendError sends a standardized error response
+// SendError sends a standardized error response. The message is translated
+// to the language negotiated with the client.
 func SendError(c *gin.Context, statusCode int, err string, message ...string) {
-	response := ErrorResponse{
-		Error: err,
+	var localized string
+	if len(message) > 0 {
+		localized = i18n.T(Translator(c), message[0])
 	}
+	sendError(c, statusCode, err, localized)
+}
 
-	if len(message) > 0 {
-		response.Message = message[0]
+// sendError sends an error response with an already translated message
+func sendError(c *gin.Context, statusCode int, err string, message string) {
+	response := ErrorResponse{
+		Error:   err,
+		Message: message,
 	}
 
-	logrus.WithFields(logrus.Fields{
+	logging.FromContext(c.Request.Context()).WithFields(logrus.Fields{
 		"status_code": statusCode,
 		"error":       err,
 		"path":        c.Request.URL.Path,
 		"method":      c.Request.Method,
 	}).Error("HTTP Error Response")
 
-	c.JSON(statusCode, response)
+	writeError(c, statusCode, response, nil)
 }
 
-// SendValidationError sends validation error response
+// SendValidationError sends validation error response. Validation errors
+// passed as details are reported field by field in problem details.
 func SendValidationError(c *gin.Context, details interface{}) {
+	trans := Translator(c)
+
+	var fields []FieldError
+	if err, ok := details.(error); ok {
+		fields = fieldErrors(err, trans)
+		details = err.Error()
+
+		var domainErr *services.Error
+		if errors.As(err, &domainErr) {
+			details = domainMessage(trans, domainErr)
+		}
+	}
+
 	response := ErrorResponse{
 		Error:   "validation_error",
-		Message: "Request validation failed",
+		Message: i18n.T(trans, "Request validation failed"),
 		Details: details,
 	}
 
-	logrus.WithFields(logrus.Fields{
+	logging.FromContext(c.Request.Context()).WithFields(logrus.Fields{
 		"error":   "validation_error",
 		"details": details,
 		"path":    c.Request.URL.Path,
 		"method":  c.Request.Method,
 	}).Warn("Validation Error")
 
-	c.JSON(http.StatusBadRequest, response)
-}
\ No newline at end of file
+	writeError(c, http.StatusBadRequest, response, fields)
+}
+
+// StatusClientClosedRequest is the non-standard status for requests the
+// client abandoned before a response was written
+const StatusClientClosedRequest = 499
+
+// errorMapping ties a kind of error to its HTTP status and error code
+type errorMapping struct {
+	kind   error
+	status int
+	code   string
+}
+
+// errorMappings is checked in order with errors.Is; unmatched errors are
+// internal server errors
+var errorMappings = []errorMapping{
+	{services.ErrValidation, http.StatusBadRequest, "validation_error"},
+	{services.ErrNotFound, http.StatusNotFound, "not_found"},
+	{services.ErrConflict, http.StatusConflict, "conflict"},
+	{context.DeadlineExceeded, http.StatusGatewayTimeout, "timeout"},
+	{context.Canceled, StatusClientClosedRequest, "client_closed_request"},
+}
+
+// StatusForError returns the HTTP status and error code for err
+func StatusForError(err error) (int, string) {
+	for _, mapping := range errorMappings {
+		if errors.Is(err, mapping.kind) {
+			return mapping.status, mapping.code
+		}
+	}
+	return http.StatusInternalServerError, "internal_server_error"
+}
+
+// SendServiceError sends the error response for an error returned by a
+// service. Only messages of domain errors reach the client; anything else is
+// logged and answered with a generic message.
+func SendServiceError(c *gin.Context, err error) {
+	statusCode, code := StatusForError(err)
+	if statusCode == http.StatusInternalServerError && errors.Is(c.Request.Context().Err(), context.Canceled) {
+		// The driver may report an aborted query with its own error
+		statusCode, code = StatusClientClosedRequest, "client_closed_request"
+	}
+
+	var domainErr *services.Error
+	switch {
+	case code == "validation_error":
+		SendValidationError(c, err)
+	case errors.As(err, &domainErr):
+		sendError(c, statusCode, code, domainMessage(Translator(c), domainErr))
+	case statusCode == http.StatusGatewayTimeout:
+		SendError(c, statusCode, code, "The request took too long to process")
+	case statusCode == StatusClientClosedRequest:
+		SendError(c, statusCode, code, "The request was cancelled")
+	default:
+		logging.FromContext(c.Request.Context()).WithError(err).WithFields(logrus.Fields{
+			"path":   c.Request.URL.Path,
+			"method": c.Request.Method,
+		}).Error("Internal error")
+		SendError(c, statusCode, code, "An unexpected error occurred")
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"transaction-api/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusForError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{&services.Error{Kind: services.ErrNotFound, Message: "transaction not found"}, http.StatusNotFound, "not_found"},
+		{&services.Error{Kind: services.ErrValidation, Message: "bad input"}, http.StatusBadRequest, "validation_error"},
+		{&services.Error{Kind: services.ErrConflict, Message: "exists"}, http.StatusConflict, "conflict"},
+		{fmt.Errorf("failed to get transactions: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, "timeout"},
+		{context.Canceled, StatusClientClosedRequest, "client_closed_request"},
+		{errors.New("connection refused"), http.StatusInternalServerError, "internal_server_error"},
+	}
+
+	for _, tt := range tests {
+		status, code := StatusForError(tt.err)
+		assert.Equal(t, tt.status, status, tt.err.Error())
+		assert.Equal(t, tt.code, code, tt.err.Error())
+	}
+}
+
+func TestSendServiceError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	send := func(err error) (int, ErrorResponse) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/transactions/1", nil)
+		SendServiceError(c, err)
+
+		var response ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response
+	}
+
+	// Domain error messages are returned to the client
+	status, response := send(&services.Error{Kind: services.ErrNotFound, Message: "transaction not found"})
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, "not_found", response.Error)
+	assert.Equal(t, "transaction not found", response.Message)
+
+	// Validation errors carry their message as details
+	status, response = send(&services.Error{Kind: services.ErrValidation, Message: "unknown failure_code: x"})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "validation_error", response.Error)
+	assert.Equal(t, "unknown failure_code: x", response.Details)
+
+	// Internal error details stay in the logs
+	status, response = send(fmt.Errorf("failed to get transaction: %w", errors.New("dial tcp 10.0.0.1:3306: connection refused")))
+	assert.Equal(t, http.StatusInternalServerError, status)
+	assert.Equal(t, "internal_server_error", response.Error)
+	assert.NotContains(t, response.Message, "10.0.0.1")
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"transaction-api/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+	ut "github.com/go-playground/universal-translator"
+)
+
+// TranslatorKey is the context key holding the request's translator
+const TranslatorKey = "translator"
+
+// Translator returns the translator for the languages the client accepts,
+// falling back to English
+func Translator(c *gin.Context) ut.Translator {
+	if trans, ok := c.Get(TranslatorKey); ok {
+		return trans.(ut.Translator)
+	}
+
+	trans := i18n.FromAcceptLanguage(c.GetHeader("Accept-Language"))
+	c.Set(TranslatorKey, trans)
+	return trans
+}
@@ -3,6 +3,9 @@ package middleware
 import (
 	"time"
 
+	"transaction-api/internal/logging"
+	"transaction-api/internal/tracing"
+
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
@@ -10,7 +13,7 @@ import (
 // LoggerMiddleware creates a gin middleware for logging requests
 func LoggerMiddleware() gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		logrus.WithFields(logrus.Fields{
+		logging.FromContext(param.Request.Context()).WithFields(logrus.Fields{
 			"status_code":  param.StatusCode,
 			"latency":      param.Latency,
 			"client_ip":    param.ClientIP,
@@ -35,4 +38,17 @@ func SetupLogger(level string) {
 	logrus.SetFormatter(&logrus.JSONFormatter{
 		TimestampFormat: time.RFC3339,
 	})
-}
\ No newline at end of file
+
+	// Add the trace and span IDs of the request to its log entries
+	logrus.AddHook(tracing.LogrusHook{})
+}
+
+// SetLogLevel changes the level of the global logger
+func SetLogLevel(level string) error {
+	logLevel, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	logrus.SetLevel(logLevel)
+	return nil
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"time"
+
+	"transaction-api/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute labels requests that matched no route, so arbitrary paths
+// do not create new series
+const unmatchedRoute = "unmatched"
+
+// Metrics records the count and latency of every request, labelled by the
+// route template rather than the raw path
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		m.ObserveRequest(route, c.Request.Method, c.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"transaction-api/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	m := metrics.New()
+	router := gin.New()
+	router.Use(Metrics(m))
+	router.GET("/transactions/:id", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/metrics", gin.WrapH(m.Handler()))
+
+	for _, path := range []string{"/transactions/1", "/transactions/2", "/missing"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	body := w.Body.String()
+
+	// Requests are labelled by route template, never by raw path
+	assert.Contains(t, body, `transaction_api_http_requests_total{method="GET",route="/transactions/:id",status="200"} 2`)
+	assert.Contains(t, body, `transaction_api_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `transaction_api_http_request_duration_seconds_count{method="GET",route="/transactions/:id",status="200"} 2`)
+	assert.NotContains(t, body, "/transactions/1")
+}
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"transaction-api/internal/i18n"
+	"transaction-api/internal/services"
+
+	"github.com/gin-gonic/gin"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details
+const ProblemContentType = "application/problem+json"
+
+// ProblemDetails is an RFC 7807 error response. Code and RequestID carry
+// the same values as the legacy ErrorResponse.
+type ProblemDetails struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes a request field that failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// wantsProblem reports whether the client negotiated problem details over
+// the legacy error shape, which remains the default
+func wantsProblem(c *gin.Context) bool {
+	return c.NegotiateFormat(gin.MIMEJSON, ProblemContentType) == ProblemContentType
+}
+
+// writeError writes response in the format negotiated with the client
+func writeError(c *gin.Context, statusCode int, response ErrorResponse, fieldErrors []FieldError) {
+	c.Header("Content-Language", Translator(c).Locale())
+	response.RequestID = c.GetString(RequestIDKey)
+
+	if !wantsProblem(c) {
+		c.JSON(statusCode, response)
+		return
+	}
+
+	// Non-standard statuses such as 499 have no status text
+	title := http.StatusText(statusCode)
+	if title == "" {
+		title = strings.ReplaceAll(response.Error, "_", " ")
+	}
+
+	problem := ProblemDetails{
+		Type:      "about:blank",
+		Title:     title,
+		Status:    statusCode,
+		Detail:    response.Message,
+		Instance:  c.Request.URL.RequestURI(),
+		Code:      response.Error,
+		RequestID: response.RequestID,
+		Errors:    fieldErrors,
+	}
+	if details, ok := response.Details.(string); ok && len(fieldErrors) == 0 {
+		problem.Detail = details
+	}
+
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(statusCode, problem)
+}
+
+// fieldErrors extracts the failing fields of a validation error, with
+// messages in the language of trans
+func fieldErrors(err error, trans ut.Translator) []FieldError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, FieldError{
+				Field:   fe.Field(),
+				Rule:    fe.Tag(),
+				Param:   fe.Param(),
+				Message: i18n.ValidationMessage(trans, fe),
+			})
+		}
+		return fields
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return []FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Param:   typeErr.Type.String(),
+			Message: i18n.T(trans, "{0} must be of type {1}", typeErr.Field, typeErr.Type.String()),
+		}}
+	}
+
+	var domainErr *services.Error
+	if errors.As(err, &domainErr) && domainErr.Field != "" {
+		return []FieldError{{
+			Field:   domainErr.Field,
+			Rule:    domainErr.Rule,
+			Message: domainMessage(trans, domainErr),
+		}}
+	}
+
+	return nil
+}
+
+// domainMessage translates the message of a domain error
+func domainMessage(trans ut.Translator, err *services.Error) string {
+	if err.Key == "" {
+		return err.Message
+	}
+	return i18n.T(trans, err.Key, err.Params...)
+}
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"transaction-api/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorContentNegotiation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/transactions/:id", func(c *gin.Context) {
+		SendError(c, http.StatusNotFound, "not_found", "transaction not found")
+	})
+
+	request := func(accept string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/transactions/7?verbose=1", nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// The legacy shape stays the default
+	for _, accept := range []string{"", "application/json", "*/*"} {
+		w := request(accept)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json", accept)
+
+		var response ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "not_found", response.Error)
+		assert.Equal(t, "transaction not found", response.Message)
+	}
+
+	// Problem details when the client asks for them
+	w := request("application/problem+json, application/json")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+
+	var problem ProblemDetails
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "about:blank", problem.Type)
+	assert.Equal(t, "Not Found", problem.Title)
+	assert.Equal(t, http.StatusNotFound, problem.Status)
+	assert.Equal(t, "transaction not found", problem.Detail)
+	assert.Equal(t, "/transactions/7?verbose=1", problem.Instance)
+	assert.Equal(t, "not_found", problem.Code)
+}
+
+func TestValidationProblem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type request struct {
+		Amount float64 `json:"amount" validate:"required,gt=0"`
+		Status string  `json:"status" validate:"oneof=pending success failed"`
+	}
+	validate := i18n.Validator()
+
+	router := gin.New()
+	router.POST("/transactions", func(c *gin.Context) {
+		SendValidationError(c, validate.Struct(&request{Amount: -1, Status: "unknown"}))
+	})
+
+	send := func(accept, language string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/transactions", nil)
+		req.Header.Set("Accept", accept)
+		req.Header.Set("Accept-Language", language)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(ProblemContentType, "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var problem ProblemDetails
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "validation_error", problem.Code)
+	assert.Equal(t, []FieldError{
+		{Field: "amount", Rule: "gt", Param: "0", Message: "amount must be greater than 0"},
+		{Field: "status", Rule: "oneof", Param: "pending success failed", Message: "status must be one of [pending success failed]"},
+	}, problem.Errors)
+
+	// Messages follow the client's language
+	w = send(ProblemContentType, "id-ID,id;q=0.9,en;q=0.8")
+	assert.Equal(t, "id", w.Header().Get("Content-Language"))
+
+	problem = ProblemDetails{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "Validasi permintaan gagal", problem.Detail)
+	assert.Equal(t, "amount harus lebih besar dari 0", problem.Errors[0].Message)
+
+	// Type errors are translated too
+	router.POST("/typed", func(c *gin.Context) {
+		var body request
+		SendValidationError(c, c.ShouldBindJSON(&body))
+	})
+	for language, message := range map[string]string{
+		"id": "amount harus bertipe float64",
+		"":   "amount must be of type float64",
+	} {
+		req, _ := http.NewRequest("POST", "/typed", strings.NewReader(`{"amount": "ten"}`))
+		req.Header.Set("Accept", ProblemContentType)
+		req.Header.Set("Accept-Language", language)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		problem = ProblemDetails{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		require.Len(t, problem.Errors, 1, language)
+		assert.Equal(t, FieldError{Field: "amount", Rule: "type", Param: "float64", Message: message}, problem.Errors[0])
+	}
+
+	// Legacy clients still get the raw validator message as details
+	w = send("application/json", "")
+	var response ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "validation_error", response.Error)
+	assert.Contains(t, response.Details, "Field validation for 'amount' failed on the 'gt' tag")
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"transaction-api/internal/logging"
+	"transaction-api/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// RateLimitClientKey is the gin context key holding the client the rate
+	// limits are applied to
+	RateLimitClientKey = "rate_limit_client"
+
+	// apiVersionPrefix is stripped from routes before looking up their
+	// policy, so versioned and legacy routes share limits
+	apiVersionPrefix = "/api/v1"
+)
+
+// RateLimit applies the limiter's policy for the route to each client,
+// identified by an issued API key in apiKeyHeader, the authenticated user or
+// the client IP. Keys that are not in apiKeys are ignored, so clients cannot
+// get fresh buckets by making keys up. Responses carry RateLimit-* headers;
+// rejected requests get 429 with Retry-After. Requests are let through when
+// the store fails.
+func RateLimit(limiter *ratelimit.Limiter, apiKeyHeader string, apiKeys []string) gin.HandlerFunc {
+	issued := make(map[[sha256.Size]byte]bool, len(apiKeys))
+	for _, key := range apiKeys {
+		issued[sha256.Sum256([]byte(key))] = true
+	}
+
+	return func(c *gin.Context) {
+		client := rateLimitClient(c, apiKeyHeader, issued)
+		c.Set(RateLimitClientKey, client)
+
+		policy := limiter.Policy(c.Request.Method, strings.TrimPrefix(c.FullPath(), apiVersionPrefix))
+		if policy == nil || c.FullPath() == "" {
+			c.Next()
+			return
+		}
+
+		decision, err := limiter.Allow(c.Request.Context(), policy, client)
+		if err != nil {
+			logging.FromContext(c.Request.Context()).WithError(err).Warn("Rate limit store failed, allowing request")
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(policy.Burst))
+		c.Header("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		c.Header("RateLimit-Reset", seconds(decision.Reset))
+		c.Header("RateLimit-Policy", policy.Header())
+
+		if !decision.Allowed {
+			c.Header("Retry-After", seconds(decision.RetryAfter))
+			if decision.QuotaExceeded {
+				SendError(c, http.StatusTooManyRequests, "quota_exceeded", "Daily quota exceeded, try again tomorrow")
+			} else {
+				SendError(c, http.StatusTooManyRequests, "rate_limited", "Too many requests, try again later")
+			}
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimitClient identifies the client by issued API key, user or IP. API
+// keys are hashed so they never appear in usage reports or logs.
+func rateLimitClient(c *gin.Context, apiKeyHeader string, issued map[[sha256.Size]byte]bool) string {
+	if apiKeyHeader != "" {
+		if key := c.GetHeader(apiKeyHeader); key != "" {
+			if sum := sha256.Sum256([]byte(key)); issued[sum] {
+				return "api_key:" + hex.EncodeToString(sum[:8])
+			}
+		}
+	}
+	if user := c.GetString(UserKey); user != "" {
+		return "user:" + user
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// seconds formats d as whole seconds, rounded up
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"transaction-api/internal/config"
+	"transaction-api/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRateLimitRouter(store ratelimit.Store) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	limiter := ratelimit.New(config.RateLimitConfig{
+		Default: config.RateLimitPolicy{Limit: 100, Period: time.Minute},
+		Routes: map[string]config.RateLimitPolicy{
+			"POST /transactions":     {Limit: 1, Period: time.Minute, Burst: 2},
+			"GET /dashboard/summary": {Limit: 100, Period: time.Minute, DailyQuota: 1},
+			"GET /transactions":      {},
+		},
+	}, store)
+
+	router := gin.New()
+	router.Use(RateLimit(limiter, "X-API-Key", []string{"secret"}))
+	ok := func(c *gin.Context) { c.String(http.StatusOK, c.GetString(RateLimitClientKey)) }
+	router.POST("/api/v1/transactions", ok)
+	router.POST("/transactions", ok)
+	router.GET("/transactions", ok)
+	router.GET("/dashboard/summary", ok)
+	router.GET("/users/:id/summary", ok)
+	return router
+}
+
+func rateLimitRequest(router *gin.Engine, method, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit(t *testing.T) {
+	router := newRateLimitRouter(ratelimit.NewMemoryStore())
+
+	w := rateLimitRequest(router, "POST", "/api/v1/transactions", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ip:192.0.2.1", w.Body.String())
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", w.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "1;w=60;burst=2", w.Header().Get("RateLimit-Policy"))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	// The legacy route shares the bucket of the versioned one
+	w = rateLimitRequest(router, "POST", "/transactions", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	w = rateLimitRequest(router, "POST", "/api/v1/transactions", nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	var response ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "rate_limited", response.Error)
+
+	// Another client, identified by an issued API key, has its own bucket
+	w = rateLimitRequest(router, "POST", "/api/v1/transactions", map[string]string{"X-API-Key": "secret"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Regexp(t, `^api_key:[0-9a-f]{16}$`, w.Body.String())
+	assert.NotContains(t, w.Body.String(), "secret")
+
+	// Other routes use the default policy
+	w = rateLimitRequest(router, "GET", "/users/1/summary", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "100", w.Header().Get("RateLimit-Limit"))
+
+	// Disabled routes are not limited
+	w = rateLimitRequest(router, "GET", "/transactions", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+}
+
+func TestRateLimitUnknownAPIKeys(t *testing.T) {
+	router := newRateLimitRouter(ratelimit.NewMemoryStore())
+
+	// Keys that were not issued do not get their own buckets, so rotating
+	// them still hits the limit of the IP
+	for i, expected := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		w := rateLimitRequest(router, "POST", "/api/v1/transactions", map[string]string{"X-API-Key": fmt.Sprintf("random-%d", i)})
+		assert.Equal(t, expected, w.Code, i)
+		if w.Code == http.StatusOK {
+			assert.Equal(t, "ip:192.0.2.1", w.Body.String())
+		}
+	}
+
+	// The issued key is still its own client
+	w := rateLimitRequest(router, "POST", "/api/v1/transactions", map[string]string{"X-API-Key": "secret"})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRateLimitIgnoresForwardedForFromUntrustedClients(t *testing.T) {
+	router := newRateLimitRouter(ratelimit.NewMemoryStore())
+	require.NoError(t, router.SetTrustedProxies(nil))
+
+	for i, expected := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		w := rateLimitRequest(router, "POST", "/api/v1/transactions", map[string]string{"X-Forwarded-For": fmt.Sprintf("198.51.100.%d", i)})
+		assert.Equal(t, expected, w.Code, i)
+	}
+
+	// Behind a trusted proxy the forwarded address is the client
+	require.NoError(t, router.SetTrustedProxies([]string{"192.0.2.0/24"}))
+	w := rateLimitRequest(router, "POST", "/api/v1/transactions", map[string]string{"X-Forwarded-For": "198.51.100.7"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ip:198.51.100.7", w.Body.String())
+}
+
+func TestRateLimitDailyQuota(t *testing.T) {
+	router := newRateLimitRouter(ratelimit.NewMemoryStore())
+
+	w := rateLimitRequest(router, "GET", "/dashboard/summary", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = rateLimitRequest(router, "GET", "/dashboard/summary", map[string]string{"Accept-Language": "id"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	var response ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "quota_exceeded", response.Error)
+	assert.Equal(t, "Kuota harian terlampaui, coba lagi besok", response.Message)
+}
+
+func TestRateLimitUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter := ratelimit.New(config.RateLimitConfig{
+		Default: config.RateLimitPolicy{Limit: 1, Period: time.Minute},
+	}, ratelimit.NewMemoryStore())
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(UserKey, c.GetHeader("X-Test-User"))
+	})
+	router.Use(RateLimit(limiter, "X-API-Key", nil))
+	router.GET("/", func(c *gin.Context) { c.String(http.StatusOK, c.GetString(RateLimitClientKey)) })
+
+	w := rateLimitRequest(router, "GET", "/", map[string]string{"X-Test-User": "alice"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "user:alice", w.Body.String())
+
+	// Users sharing an IP are limited separately
+	w = rateLimitRequest(router, "GET", "/", map[string]string{"X-Test-User": "bob"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = rateLimitRequest(router, "GET", "/", map[string]string{"X-Test-User": "alice"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+// failingStore is a rate limit store that is down
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, *ratelimit.Policy, time.Time) (ratelimit.Decision, error) {
+	return ratelimit.Decision{}, errors.New("store unavailable")
+}
+
+func (failingStore) Usage(context.Context, string, *ratelimit.Policy, time.Time) (ratelimit.Usage, error) {
+	return ratelimit.Usage{}, errors.New("store unavailable")
+}
+
+func TestRateLimitStoreFailure(t *testing.T) {
+	router := newRateLimitRouter(failingStore{})
+
+	for i := 0; i < 3; i++ {
+		w := rateLimitRequest(router, "POST", "/api/v1/transactions", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	}
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ReadConsistencyHeader lets a client force reads from the primary with "strong"
+	ReadConsistencyHeader = "X-Read-Consistency"
+
+	// ReadConsistencyTokenHeader carries the token returned to
+	// unauthenticated clients after a write. Sending it back keeps their
+	// reads on the primary during the sticky window.
+	ReadConsistencyTokenHeader = "X-Read-Consistency-Token"
+
+	// ReadFromPrimaryKey is the gin context key set when reads must use the primary
+	ReadFromPrimaryKey = "read_from_primary"
+)
+
+// ReadConsistency decides per request whether reads must go to the primary
+// database. Reads use the primary when the client asks for strong consistency,
+// or when the same client wrote within the sticky window, so a client that
+// just created a transaction can immediately fetch it. Authenticated clients
+// are recognized by their identity. Other clients are given a token after a
+// write and are only recognized when they send it back; client IPs are not
+// used, as they are shared behind NAT.
+func ReadConsistency(window time.Duration) gin.HandlerFunc {
+	var mu sync.Mutex
+	lastWrites := make(map[string]time.Time)
+
+	return func(c *gin.Context) {
+		client := readConsistencyClient(c)
+		now := time.Now()
+
+		primary := strings.EqualFold(c.GetHeader(ReadConsistencyHeader), "strong")
+		known := false
+		if window > 0 && client != "" {
+			mu.Lock()
+			if lastWrite, ok := lastWrites[client]; ok {
+				if now.Sub(lastWrite) < window {
+					primary, known = true, true
+				} else {
+					delete(lastWrites, client)
+				}
+			}
+			mu.Unlock()
+		}
+		c.Set(ReadFromPrimaryKey, primary)
+
+		// The token is issued before the handler writes the response
+		if window > 0 && isWriteMethod(c.Request.Method) && c.GetString(UserKey) == "" {
+			if !known {
+				client = "token:" + newReadConsistencyToken()
+			}
+			c.Header(ReadConsistencyTokenHeader, strings.TrimPrefix(client, "token:"))
+		}
+
+		c.Next()
+
+		if window <= 0 || client == "" || !isWriteMethod(c.Request.Method) || c.Writer.Status() >= 400 {
+			return
+		}
+
+		mu.Lock()
+		lastWrites[client] = time.Now()
+		// Drop expired entries so the map does not grow without bound
+		if len(lastWrites) > 1024 {
+			for key, lastWrite := range lastWrites {
+				if now.Sub(lastWrite) >= window {
+					delete(lastWrites, key)
+				}
+			}
+		}
+		mu.Unlock()
+	}
+}
+
+// readConsistencyClient identifies the client by its authenticated identity
+// or by the token it sent back, or returns "" for an unknown client
+func readConsistencyClient(c *gin.Context) string {
+	if user := c.GetString(UserKey); user != "" {
+		return "user:" + user
+	}
+	if token := c.GetHeader(ReadConsistencyTokenHeader); token != "" {
+		return "token:" + token
+	}
+	return ""
+}
+
+// newReadConsistencyToken returns a random token that cannot be guessed, so
+// clients cannot pin the reads of others to the primary
+func newReadConsistencyToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func isWriteMethod(method string) bool {
+	switch method {
+	case "POST", "PUT", "PATCH", "DELETE":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadConsistency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if user := c.GetHeader("X-Test-User"); user != "" {
+			c.Set(UserKey, user)
+		}
+	})
+	router.Use(ReadConsistency(time.Minute))
+	router.GET("/transactions", func(c *gin.Context) {
+		c.String(http.StatusOK, strconv.FormatBool(c.GetBool(ReadFromPrimaryKey)))
+	})
+	router.POST("/transactions", func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+	router.PUT("/transactions", func(c *gin.Context) {
+		c.Status(http.StatusBadRequest)
+	})
+
+	send := func(method string, headers map[string]string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/transactions", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	read := func(headers map[string]string) string {
+		return send("GET", headers).Body.String()
+	}
+
+	// Reads use replicas by default
+	assert.Equal(t, "false", read(nil))
+
+	// Clients can ask for strong consistency
+	assert.Equal(t, "true", read(map[string]string{ReadConsistencyHeader: "strong"}))
+
+	// Failed writes do not make the client sticky
+	failed := send("PUT", nil).Header().Get(ReadConsistencyTokenHeader)
+	assert.Equal(t, "false", read(map[string]string{ReadConsistencyTokenHeader: failed}))
+
+	// Unauthenticated clients are sticky only when they send back the token
+	// of their write, not by sharing its IP
+	token := send("POST", nil).Header().Get(ReadConsistencyTokenHeader)
+	assert.Len(t, token, 32)
+	assert.Equal(t, "true", read(map[string]string{ReadConsistencyTokenHeader: token}))
+	assert.Equal(t, "false", read(nil))
+	assert.Equal(t, "false", read(map[string]string{ReadConsistencyTokenHeader: "guessed"}))
+
+	// Writing again with the token keeps it
+	assert.Equal(t, token, send("POST", map[string]string{ReadConsistencyTokenHeader: token}).Header().Get(ReadConsistencyTokenHeader))
+
+	// Authenticated clients are sticky by identity and get no token
+	w := send("POST", map[string]string{"X-Test-User": "CN=billing"})
+	assert.Empty(t, w.Header().Get(ReadConsistencyTokenHeader))
+	assert.Equal(t, "true", read(map[string]string{"X-Test-User": "CN=billing"}))
+	assert.Equal(t, "false", read(map[string]string{"X-Test-User": "CN=reports"}))
+}
+
+func TestReadConsistencyWindowExpires(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(ReadConsistency(20 * time.Millisecond))
+	router.Any("/transactions", func(c *gin.Context) {
+		c.String(http.StatusOK, strconv.FormatBool(c.GetBool(ReadFromPrimaryKey)))
+	})
+
+	req, _ := http.NewRequest("POST", "/transactions", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	token := w.Header().Get(ReadConsistencyTokenHeader)
+
+	time.Sleep(30 * time.Millisecond)
+
+	req, _ = http.NewRequest("GET", "/transactions", nil)
+	req.Header.Set(ReadConsistencyTokenHeader, token)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "false", w.Body.String())
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"regexp"
+
+	"transaction-api/internal/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// RequestIDHeader carries the request ID in requests and responses
+	RequestIDHeader = "X-Request-ID"
+
+	// RequestIDKey is the gin context key holding the request ID
+	RequestIDKey = "request_id"
+
+	// UserKey is the gin context key holding the authenticated user, set by
+	// authentication middleware
+	UserKey = "user"
+)
+
+// validRequestID limits accepted request IDs to short tokens that are safe to
+// log and echo
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID accepts the client's X-Request-ID, or generates one when it is
+// missing or malformed, and echoes it in the response. The ID is stored in
+// the request context along with a logger carrying request_id, route and
+// caller fields.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		c.Set(RequestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		ctx := logging.WithRequestID(c.Request.Context(), requestID)
+		ctx = logging.WithFields(ctx, logrus.Fields{
+			"request_id": requestID,
+			"route":      route,
+			"caller":     callerIdentity(c),
+		})
+		c.Request = c.Request.WithContext(ctx)
+
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("request.id", requestID))
+
+		c.Next()
+	}
+}
+
+// callerIdentity identifies who is making the request: the authenticated
+// user, or the client IP when the request is not authenticated
+func callerIdentity(c *gin.Context) string {
+	if user := c.GetString(UserKey); user != "" {
+		return user
+	}
+	return c.ClientIP()
+}
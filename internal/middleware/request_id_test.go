@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"transaction-api/internal/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/transactions/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, logging.RequestIDFromContext(c.Request.Context()))
+	})
+
+	request := func(header string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/transactions/1", nil)
+		if header != "" {
+			req.Header.Set(RequestIDHeader, header)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// A valid client ID is accepted and echoed
+	w := request("req-123.abc:1")
+	assert.Equal(t, "req-123.abc:1", w.Header().Get(RequestIDHeader))
+	assert.Equal(t, "req-123.abc:1", w.Body.String())
+
+	// Missing or malformed IDs are replaced by a generated one
+	for _, header := range []string{"", "has spaces", "<script>", strings.Repeat("a", 129)} {
+		w := request(header)
+		generated := w.Header().Get(RequestIDHeader)
+		_, err := uuid.Parse(generated)
+		assert.NoError(t, err, header)
+		assert.Equal(t, generated, w.Body.String(), header)
+	}
+	assert.NotEqual(t, request("").Header().Get(RequestIDHeader), request("").Header().Get(RequestIDHeader))
+}
+
+func TestRequestIDInErrorResponses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/fail", func(c *gin.Context) {
+		SendError(c, http.StatusNotFound, "not_found", "transaction not found")
+	})
+
+	request := func(accept string) map[string]interface{} {
+		req, _ := http.NewRequest("GET", "/fail", nil)
+		req.Header.Set(RequestIDHeader, "req-42")
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+
+	legacy := request("")
+	assert.Equal(t, "not_found", legacy["error"])
+	assert.Equal(t, "req-42", legacy["request_id"])
+
+	problem := request(ProblemContentType)
+	assert.Equal(t, "not_found", problem["code"])
+	assert.Equal(t, "req-42", problem["request_id"])
+}
+
+func TestRequestIDLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	logrus.SetOutput(&buf)
+	logrus.SetFormatter(&logrus.JSONFormatter{})
+	t.Cleanup(func() { logrus.SetOutput(os.Stderr) })
+
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/transactions/:id", func(c *gin.Context) {
+		logging.FromContext(c.Request.Context()).Info("handling")
+		c.Status(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest("GET", "/transactions/7", nil)
+	req.Header.Set(RequestIDHeader, "req-7")
+	req.RemoteAddr = "203.0.113.9:1234"
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "req-7", entry["request_id"])
+	assert.Equal(t, "/transactions/:id", entry["route"])
+	assert.Equal(t, "203.0.113.9", entry["caller"])
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"transaction-api/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	router := gin.New()
+	router.Use(Tracing())
+	router.GET("/transactions/:id", func(c *gin.Context) {
+		_, span := tracing.Start(c.Request.Context(), "handler")
+		span.End()
+		c.Status(http.StatusOK)
+	})
+	router.GET("/broken", func(c *gin.Context) {
+		c.Status(http.StatusInternalServerError)
+	})
+
+	// An incoming traceparent is continued
+	req, _ := http.NewRequest("GET", "/transactions/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	child, server := spans[0], spans[1]
+
+	assert.Equal(t, "GET /transactions/:id", server.Name())
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+	assert.True(t, server.Parent().IsRemote())
+	assert.Equal(t, server.SpanContext().SpanID(), child.Parent().SpanID())
+
+	// Without one a new trace starts, and server errors mark the span
+	req, _ = http.NewRequest("GET", "/broken", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans = recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.False(t, spans[2].Parent().IsValid())
+	assert.Equal(t, codes.Error, spans[2].Status().Code)
+}
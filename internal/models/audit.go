@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// AuditAction is the kind of mutation an audit event records
+type AuditAction string
+
+const (
+	AuditActionCreate AuditAction = "create"
+	AuditActionUpdate AuditAction = "update"
+	AuditActionDelete AuditAction = "delete"
+)
+
+// AuditEntityTransaction is the entity type of transaction audit events
+const AuditEntityTransaction = "transaction"
+
+// AuditEvent is an append-only record of a mutation. Events form a hash
+// chain: Hash covers the event and PrevHash, the Hash of the event with the
+// previous Sequence, so altering or removing an event breaks the chain.
+type AuditEvent struct {
+	ID         uint        `json:"id" gorm:"primaryKey"`
+	Sequence   uint64      `json:"sequence" gorm:"not null;uniqueIndex"`
+	Action     AuditAction `json:"action" gorm:"size:16;not null"`
+	EntityType string      `json:"entity_type" gorm:"size:32;not null;index:idx_audit_events_entity"`
+	EntityID   uint        `json:"entity_id" gorm:"not null;index:idx_audit_events_entity"`
+	Actor      string      `json:"actor" gorm:"size:255;not null;default:''"`
+	IP         string      `json:"ip" gorm:"size:64;not null;default:''"`
+	RequestID  string      `json:"request_id" gorm:"size:128;not null;default:''"`
+	// Before and After are JSON snapshots of the entity; Changes maps each
+	// changed field to its before and after values
+	Before    RawJSON   `json:"before" gorm:"column:before_state;type:text"`
+	After     RawJSON   `json:"after" gorm:"column:after_state;type:text"`
+	Changes   RawJSON   `json:"changes" gorm:"type:text"`
+	PrevHash  string    `json:"prev_hash" gorm:"size:64;not null"`
+	Hash      string    `json:"hash" gorm:"size:64;not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// RawJSON is JSON stored as text and embedded as is in responses. The empty
+// string is encoded as null.
+type RawJSON string
+
+// MarshalJSON implements json.Marshaler
+func (j RawJSON) MarshalJSON() ([]byte, error) {
+	if j == "" {
+		return []byte("null"), nil
+	}
+	return []byte(j), nil
+}
+
+// AuditQuery represents query parameters for filtering audit events
+type AuditQuery struct {
+	EntityID  uint        `form:"entity_id"`
+	Action    AuditAction `form:"action"`
+	Actor     string      `form:"actor"`
+	RequestID string      `form:"request_id"`
+	From      *time.Time  `form:"-"`
+	To        *time.Time  `form:"-"`
+	Limit     int         `form:"limit"`
+	Page      int         `form:"page"`
+}
+
+// AuditEventResponse represents a page of audit events, newest first
+type AuditEventResponse struct {
+	Data       []AuditEvent `json:"data"`
+	Total      int64        `json:"total"`
+	Page       int          `json:"page"`
+	Limit      int          `json:"limit"`
+	TotalPages int          `json:"total_pages"`
+}
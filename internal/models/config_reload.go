@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// ConfigReload statuses
+const (
+	ConfigReloadApplied = "applied"
+	ConfigReloadFailed  = "failed"
+)
+
+// ConfigReload is the outcome of reloading the configuration on SIGHUP
+type ConfigReload struct {
+	ReloadedAt time.Time `json:"reloaded_at"`
+	Status     string    `json:"status"`
+	// Applied are the changed settings now in effect
+	Applied []string `json:"applied"`
+	// Rejected are the changed settings that need a restart to take effect
+	Rejected []string `json:"rejected"`
+	// Invalid are the settings that made a failed reload change nothing.
+	// Their values and the reasons are only logged.
+	Invalid []string `json:"invalid,omitempty"`
+}
+
+// ConfigReloadStatusResponse reports the last configuration reload, which is
+// null until the server has been reloaded
+type ConfigReloadStatusResponse struct {
+	LastReload *ConfigReload `json:"last_reload"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// RateLimitUsageResponse reports a client's usage of every rate limit policy
+type RateLimitUsageResponse struct {
+	Client   string           `json:"client"`
+	Policies []RateLimitUsage `json:"policies"`
+}
+
+// RateLimitUsage is a client's usage of one rate limit policy
+type RateLimitUsage struct {
+	// Policy is "default" or the "METHOD /path" route it applies to
+	Policy     string          `json:"policy"`
+	Limit      int             `json:"limit"`
+	Period     string          `json:"period"`
+	Burst      int             `json:"burst"`
+	Remaining  int             `json:"remaining"`
+	DailyQuota *RateLimitQuota `json:"daily_quota,omitempty"`
+}
+
+// RateLimitQuota is a client's usage of a daily quota
+type RateLimitQuota struct {
+	Limit     int64     `json:"limit"`
+	Used      int64     `json:"used"`
+	Remaining int64     `json:"remaining"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
@@ -15,13 +15,26 @@ const (
 )
 
 type Transaction struct {
-	ID        uint              `json:"id" gorm:"primaryKey"`
-	UserID    uint              `json:"user_id" gorm:"not null;index" validate:"required"`
-	Amount    float64           `json:"amount" gorm:"not null" validate:"required,gt=0"`
-	Status    TransactionStatus `json:"status" gorm:"not null;default:'pending'" validate:"required,oneof=pending success failed"`
-	CreatedAt time.Time         `json:"created_at"`
-	UpdatedAt time.Time         `json:"updated_at"`
-	DeletedAt gorm.DeletedAt    `json:"-" gorm:"index"`
+	ID             uint              `json:"id" gorm:"primaryKey"`
+	UserID         uint              `json:"user_id" gorm:"not null;index" validate:"required"`
+	Amount         float64           `json:"amount" gorm:"not null" validate:"required,gt=0"`
+	Status         TransactionStatus `json:"status" gorm:"not null;default:'pending'" validate:"required,oneof=pending success failed"`
+	FailureCode    string            `json:"failure_code,omitempty" gorm:"size:64;not null;default:'';index"`
+	FailureMessage string            `json:"failure_message,omitempty" gorm:"size:255;not null;default:''"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt    `json:"-" gorm:"index"`
+}
+
+// DefaultFailureCodes is the failure code catalog used when none is configured
+var DefaultFailureCodes = []string{
+	"insufficient_funds",
+	"timeout",
+	"declined",
+	"invalid_account",
+	"limit_exceeded",
+	"fraud_suspected",
+	"provider_error",
 }
 
 // TransactionRequest represents the request payload for creating transactions
@@ -32,7 +45,9 @@ type TransactionRequest struct {
 
 // TransactionUpdateRequest represents the request payload for updating transactions
 type TransactionUpdateRequest struct {
-	Status TransactionStatus `json:"status" validate:"required,oneof=pending success failed"`
+	Status         TransactionStatus `json:"status" validate:"required,oneof=pending success failed"`
+	FailureCode    string            `json:"failure_code,omitempty" validate:"required_if=Status failed"`
+	FailureMessage string            `json:"failure_message,omitempty" validate:"max=255"`
 }
 
 // TransactionQuery represents query parameters for filtering transactions
@@ -53,13 +68,180 @@ type TransactionResponse struct {
 	TotalPages int           `json:"total_pages"`
 }
 
+// CompareMode selects the period a dashboard summary is compared against
+type CompareMode string
+
+const (
+	ComparePreviousPeriod CompareMode = "previous_period"
+	ComparePreviousYear   CompareMode = "previous_year"
+)
+
+// DashboardQuery represents the parameters for building a dashboard summary.
+// When From is nil the summary covers the current UTC day.
+type DashboardQuery struct {
+	From     *time.Time
+	To       *time.Time
+	Compare  CompareMode
+	TopUsers int
+}
+
 // DashboardSummary represents the dashboard summary data
 type DashboardSummary struct {
-	TotalSuccessToday     int64   `json:"total_success_today"`
-	AverageAmountPerUser  float64 `json:"average_amount_per_user"`
-	TotalTransactions     int64   `json:"total_transactions"`
-	RecentTransactions    []Transaction `json:"recent_transactions"`
-	TotalAmount           float64 `json:"total_amount"`
-	TotalAmountToday      float64 `json:"total_amount_today"`
-	StatusDistribution    map[string]int64 `json:"status_distribution"`
-}
\ No newline at end of file
+	PeriodFrom               time.Time            `json:"period_from"`
+	PeriodTo                 time.Time            `json:"period_to"`
+	TotalSuccessToday        int64                `json:"total_success_today"`
+	AverageAmountPerUser     float64              `json:"average_amount_per_user"`
+	AverageTransactionAmount float64              `json:"average_transaction_amount"`
+	TotalTransactions        int64                `json:"total_transactions"`
+	RecentTransactions       []Transaction        `json:"recent_transactions"`
+	TotalAmount              float64              `json:"total_amount"`
+	TotalAmountToday         float64              `json:"total_amount_today"`
+	StatusDistribution       map[string]int64     `json:"status_distribution"`
+	Comparison               *DashboardComparison `json:"comparison,omitempty"`
+	TopUsers                 []TopUser            `json:"top_users,omitempty"`
+}
+
+// DashboardComparison holds the previous value of every dashboard metric
+type DashboardComparison struct {
+	Mode         CompareMode                 `json:"mode"`
+	PreviousFrom time.Time                   `json:"previous_from"`
+	PreviousTo   time.Time                   `json:"previous_to"`
+	Metrics      map[string]MetricComparison `json:"metrics"`
+}
+
+// MetricComparison represents a metric compared with its previous value.
+// PercentChange is nil when the previous value is zero.
+type MetricComparison struct {
+	Current       float64  `json:"current"`
+	Previous      float64  `json:"previous"`
+	Delta         float64  `json:"delta"`
+	PercentChange *float64 `json:"percent_change"`
+}
+
+// TopUsersBy selects the metric users are ranked by
+type TopUsersBy string
+
+const (
+	TopUsersByAmount TopUsersBy = "amount"
+	TopUsersByCount  TopUsersBy = "count"
+)
+
+// TopUsersQuery represents the parameters for the top users leaderboard
+type TopUsersQuery struct {
+	By     TopUsersBy        `form:"by"`
+	Status TransactionStatus `form:"status"`
+	From   *time.Time        `form:"-"`
+	To     *time.Time        `form:"-"`
+	Limit  int               `form:"limit"`
+}
+
+// TopUser represents a user's position in the leaderboard. Share is the
+// user's fraction of the ranked metric over all matching transactions.
+type TopUser struct {
+	UserID           uint    `json:"user_id"`
+	TransactionCount int64   `json:"transaction_count"`
+	TotalAmount      float64 `json:"total_amount"`
+	Share            float64 `json:"share"`
+}
+
+// TopUsersResponse represents the top users leaderboard
+type TopUsersResponse struct {
+	By               TopUsersBy `json:"by"`
+	TransactionCount int64      `json:"transaction_count"`
+	TotalAmount      float64    `json:"total_amount"`
+	Users            []TopUser  `json:"users"`
+}
+
+// BucketScale selects how amount histogram buckets are spaced
+type BucketScale string
+
+const (
+	ScaleLinear      BucketScale = "linear"
+	ScaleLogarithmic BucketScale = "log"
+)
+
+// DistributionQuery represents the parameters for the amount distribution
+type DistributionQuery struct {
+	UserID  uint              `form:"user_id"`
+	Status  TransactionStatus `form:"status"`
+	From    *time.Time        `form:"-"`
+	To      *time.Time        `form:"-"`
+	Buckets int               `form:"buckets"`
+	Scale   BucketScale       `form:"scale"`
+	Min     *float64          `form:"min"`
+	Max     *float64          `form:"max"`
+}
+
+// AmountDistribution represents the amount histogram and percentiles
+type AmountDistribution struct {
+	Scale       BucketScale       `json:"scale"`
+	Count       int64             `json:"count"`
+	Min         float64           `json:"min"`
+	Max         float64           `json:"max"`
+	Buckets     []AmountBucket    `json:"buckets"`
+	Percentiles AmountPercentiles `json:"percentiles"`
+}
+
+// AmountBucket represents a histogram bucket covering [LowerBound, UpperBound).
+// The last bucket also includes its upper bound.
+type AmountBucket struct {
+	LowerBound float64 `json:"lower_bound"`
+	UpperBound float64 `json:"upper_bound"`
+	Count      int64   `json:"count"`
+}
+
+// AmountPercentiles represents amount percentiles using the nearest-rank method
+type AmountPercentiles struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+}
+
+// FailureInterval selects the time bucket size of the failure breakdown
+type FailureInterval string
+
+const (
+	IntervalHour FailureInterval = "hour"
+	IntervalDay  FailureInterval = "day"
+)
+
+// FailureQuery represents the parameters for the failure breakdown
+type FailureQuery struct {
+	From     time.Time
+	To       time.Time
+	Interval FailureInterval
+}
+
+// FailureBreakdown represents failures by code over time
+type FailureBreakdown struct {
+	Interval          FailureInterval  `json:"interval"`
+	From              time.Time        `json:"from"`
+	To                time.Time        `json:"to"`
+	TotalTransactions int64            `json:"total_transactions"`
+	TotalFailed       int64            `json:"total_failed"`
+	FailureRate       float64          `json:"failure_rate"`
+	ByCode            map[string]int64 `json:"by_code"`
+	Series            []FailureBucket  `json:"series"`
+}
+
+// FailureBucket represents the failures of a single time bucket
+type FailureBucket struct {
+	Start             time.Time        `json:"start"`
+	TotalTransactions int64            `json:"total_transactions"`
+	TotalFailed       int64            `json:"total_failed"`
+	FailureRate       float64          `json:"failure_rate"`
+	ByCode            map[string]int64 `json:"by_code"`
+}
+
+// UserSummary represents the transaction statistics of a single user
+type UserSummary struct {
+	UserID             uint             `json:"user_id"`
+	TransactionCount   int64            `json:"transaction_count"`
+	SuccessRate        float64          `json:"success_rate"`
+	TotalAmount        float64          `json:"total_amount"`
+	AverageAmount      float64          `json:"average_amount"`
+	FirstTransactionAt *time.Time       `json:"first_transaction_at"`
+	LastTransactionAt  *time.Time       `json:"last_transaction_at"`
+	StatusDistribution map[string]int64 `json:"status_distribution"`
+}
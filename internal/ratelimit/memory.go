@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the memory store drops idle buckets and past
+// quota counters
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// full is when the bucket refills completely and can be dropped
+	full time.Time
+}
+
+type quotaCounter struct {
+	day  string
+	used int64
+}
+
+// MemoryStore keeps buckets and quotas in process memory. Limits are per
+// instance and reset on restart.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	quotas    map[string]*quotaCounter
+	lastSweep time.Time
+}
+
+// NewMemoryStore returns an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		quotas:  make(map[string]*quotaCounter),
+	}
+}
+
+// Take implements Store
+func (s *MemoryStore) Take(ctx context.Context, key string, policy *Policy, now time.Time) (Decision, error) {
+	if err := ctx.Err(); err != nil {
+		return Decision{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	tokens := s.tokens(key, policy, now)
+	quota := s.quota(key, now)
+
+	decision := Decision{QuotaUsed: quota.used}
+	switch {
+	case policy.DailyQuota > 0 && quota.used >= policy.DailyQuota:
+		decision.QuotaExceeded = true
+		decision.RetryAfter = nextDay(now).Sub(now)
+	case tokens < 1:
+		decision.RetryAfter = secondsToDuration((1 - tokens) / policy.Rate)
+	default:
+		decision.Allowed = true
+		tokens--
+		quota.used++
+		decision.QuotaUsed = quota.used
+		s.quotas[key] = quota
+	}
+
+	s.buckets[key] = &bucket{
+		tokens:  tokens,
+		updated: now,
+		full:    now.Add(secondsToDuration((float64(policy.Burst) - tokens) / policy.Rate)),
+	}
+	decision.Remaining = int(tokens)
+	decision.Reset = s.buckets[key].full.Sub(now)
+	return decision, nil
+}
+
+// Usage implements Store
+func (s *MemoryStore) Usage(ctx context.Context, key string, policy *Policy, now time.Time) (Usage, error) {
+	if err := ctx.Err(); err != nil {
+		return Usage{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return Usage{Tokens: s.tokens(key, policy, now), QuotaUsed: s.quota(key, now).used}, nil
+}
+
+// tokens returns the tokens in the bucket of key, refilled up to now
+func (s *MemoryStore) tokens(key string, policy *Policy, now time.Time) float64 {
+	b, ok := s.buckets[key]
+	if !ok {
+		return float64(policy.Burst)
+	}
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return math.Min(float64(policy.Burst), b.tokens+elapsed*policy.Rate)
+}
+
+// quota returns the counter of key for the day of now
+func (s *MemoryStore) quota(key string, now time.Time) *quotaCounter {
+	today := day(now)
+	if q, ok := s.quotas[key]; ok && q.day == today {
+		return q
+	}
+	return &quotaCounter{day: today}
+}
+
+// sweep drops full buckets and counters of past days, so clients that went
+// away do not hold memory. The caller must hold s.mu.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+	today := day(now)
+	for key, q := range s.quotas {
+		if q.day != today {
+			delete(s.quotas, key)
+		}
+	}
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
@@ -0,0 +1,167 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPolicy(limit int, period time.Duration, burst int, quota int64) *Policy {
+	return &Policy{
+		Name:       "test",
+		Limit:      limit,
+		Period:     period,
+		Rate:       float64(limit) / period.Seconds(),
+		Burst:      burst,
+		DailyQuota: quota,
+	}
+}
+
+func TestMemoryStoreTokenBucket(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	policy := testPolicy(1, time.Second, 3, 0)
+	now := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+
+	// The bucket starts full and allows a burst
+	for i := 2; i >= 0; i-- {
+		decision, err := store.Take(ctx, "client", policy, now)
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, i, decision.Remaining)
+		assert.Equal(t, time.Duration(3-i)*time.Second, decision.Reset)
+	}
+
+	decision, err := store.Take(ctx, "client", policy, now)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.False(t, decision.QuotaExceeded)
+	assert.Equal(t, time.Second, decision.RetryAfter)
+
+	// Tokens refill at the policy rate
+	decision, err = store.Take(ctx, "client", policy, now.Add(500*time.Millisecond))
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, 500*time.Millisecond, decision.RetryAfter)
+
+	decision, err = store.Take(ctx, "client", policy, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+
+	// Other clients have their own bucket
+	decision, err = store.Take(ctx, "other", policy, now)
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+
+	// The bucket never holds more than the burst
+	usage, err := store.Usage(ctx, "client", policy, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, usage.Tokens)
+}
+
+func TestMemoryStoreDailyQuota(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	policy := testPolicy(100, time.Second, 100, 2)
+	now := time.Date(2024, 3, 10, 23, 0, 0, 0, time.UTC)
+
+	for i := 1; i <= 2; i++ {
+		decision, err := store.Take(ctx, "client", policy, now)
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, int64(i), decision.QuotaUsed)
+	}
+
+	decision, err := store.Take(ctx, "client", policy, now)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.True(t, decision.QuotaExceeded)
+	assert.Equal(t, time.Hour, decision.RetryAfter)
+
+	usage, err := store.Usage(ctx, "client", policy, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), usage.QuotaUsed)
+
+	// Quotas reset at UTC midnight
+	decision, err = store.Take(ctx, "client", policy, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+	assert.Equal(t, int64(1), decision.QuotaUsed)
+}
+
+func TestMemoryStoreRejectedRequestsTakeNothing(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	policy := testPolicy(1, time.Minute, 1, 10)
+	now := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+
+	_, err := store.Take(ctx, "client", policy, now)
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		decision, err := store.Take(ctx, "client", policy, now)
+		require.NoError(t, err)
+		assert.False(t, decision.Allowed)
+	}
+
+	usage, err := store.Usage(ctx, "client", policy, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), usage.QuotaUsed)
+	assert.Equal(t, 0.0, usage.Tokens)
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	policy := testPolicy(1, time.Second, 1, 10)
+	now := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+
+	_, err := store.Take(ctx, "idle", policy, now)
+	require.NoError(t, err)
+	_, err = store.Take(ctx, "active", policy, now.Add(2*sweepInterval))
+	require.NoError(t, err)
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	assert.NotContains(t, store.buckets, "idle")
+	assert.Contains(t, store.buckets, "active")
+	// The idle client's quota is kept until the day is over
+	assert.Contains(t, store.quotas, "idle")
+}
+
+func TestMemoryStoreConcurrency(t *testing.T) {
+	store := NewMemoryStore()
+	policy := testPolicy(1, time.Hour, 50, 0)
+	now := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			decision, err := store.Take(context.Background(), "client", policy, now)
+			assert.NoError(t, err)
+			if decision.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 50, allowed)
+}
+
+func TestMemoryStoreCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewMemoryStore().Take(ctx, "client", testPolicy(1, time.Second, 1, 0), time.Now())
+	assert.ErrorIs(t, err, context.Canceled)
+}
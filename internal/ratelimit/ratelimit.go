@@ -0,0 +1,212 @@
+// Package ratelimit limits the requests of each client with token buckets
+// and daily quotas kept in a pluggable Store.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"transaction-api/internal/config"
+	"transaction-api/internal/models"
+)
+
+// DefaultPolicy names the policy of routes without their own
+const DefaultPolicy = "default"
+
+// Policy is a token bucket refilled at Rate tokens per second up to Burst
+// tokens, plus an optional daily quota
+type Policy struct {
+	Name string
+	// Limit requests per Period, as configured
+	Limit  int
+	Period time.Duration
+	Rate   float64
+	Burst  int
+	// DailyQuota caps the requests per UTC day; zero is unlimited
+	DailyQuota int64
+}
+
+func newPolicy(name string, cfg config.RateLimitPolicy) *Policy {
+	if cfg.Limit <= 0 || cfg.Period <= 0 {
+		return nil
+	}
+	burst := cfg.Burst
+	if burst <= 0 {
+		burst = cfg.Limit
+	}
+	return &Policy{
+		Name:       name,
+		Limit:      cfg.Limit,
+		Period:     cfg.Period,
+		Rate:       float64(cfg.Limit) / cfg.Period.Seconds(),
+		Burst:      burst,
+		DailyQuota: cfg.DailyQuota,
+	}
+}
+
+// Header returns the policy in the RateLimit-Policy header format
+func (p *Policy) Header() string {
+	header := fmt.Sprintf("%d;w=%d", p.Limit, int64(p.Period.Seconds()))
+	if p.Burst != p.Limit {
+		header += fmt.Sprintf(";burst=%d", p.Burst)
+	}
+	if p.DailyQuota > 0 {
+		header += fmt.Sprintf(";quota=%d", p.DailyQuota)
+	}
+	return header
+}
+
+// Decision is the outcome of a request against a policy
+type Decision struct {
+	Allowed bool
+	// Remaining whole tokens and the time until the bucket is full again
+	Remaining int
+	Reset     time.Duration
+	// RetryAfter is how long a rejected client should wait
+	RetryAfter time.Duration
+
+	QuotaUsed     int64
+	QuotaExceeded bool
+}
+
+// Usage is the state of a client's bucket and quota under a policy
+type Usage struct {
+	Tokens    float64
+	QuotaUsed int64
+}
+
+// Store keeps the buckets and quota counters. Implementations must apply
+// Take atomically, so a shared store can serve several instances.
+type Store interface {
+	// Take takes a token from the bucket of key and counts the request
+	// against the quota of key for the day of now. A rejected request takes
+	// nothing and is not counted.
+	Take(ctx context.Context, key string, policy *Policy, now time.Time) (Decision, error)
+	// Usage returns the state of key without taking a token
+	Usage(ctx context.Context, key string, policy *Policy, now time.Time) (Usage, error)
+}
+
+// Limiter applies the configured policies to clients
+type Limiter struct {
+	store    Store
+	policies atomic.Pointer[policies]
+	now      func() time.Time
+}
+
+// policies are the default and per route policies of a configuration,
+// replaced as a whole by Update
+type policies struct {
+	defaultPolicy *Policy
+	routes        map[string]*Policy
+}
+
+// New returns a limiter applying the policies of cfg with store
+func New(cfg config.RateLimitConfig, store Store) *Limiter {
+	l := &Limiter{store: store, now: time.Now}
+	l.Update(cfg)
+	return l
+}
+
+// Update replaces the policies with those of cfg. Buckets and quotas are
+// kept, so clients do not regain tokens when the limits change.
+func (l *Limiter) Update(cfg config.RateLimitConfig) {
+	p := &policies{
+		defaultPolicy: newPolicy(DefaultPolicy, cfg.Default),
+		routes:        make(map[string]*Policy, len(cfg.Routes)),
+	}
+	for route, policy := range cfg.Routes {
+		// A disabled route policy is kept so the route is not limited by
+		// the default policy
+		p.routes[route] = newPolicy(route, policy)
+	}
+	l.policies.Store(p)
+}
+
+// Policy returns the policy for a route pattern, or nil when the route is
+// not limited
+func (l *Limiter) Policy(method, route string) *Policy {
+	p := l.policies.Load()
+	if policy, ok := p.routes[method+" "+route]; ok {
+		return policy
+	}
+	return p.defaultPolicy
+}
+
+// Allow takes a token for client under policy
+func (l *Limiter) Allow(ctx context.Context, policy *Policy, client string) (Decision, error) {
+	return l.store.Take(ctx, storeKey(policy, client), policy, l.now())
+}
+
+// Usage reports the usage of client under every policy
+func (l *Limiter) Usage(ctx context.Context, client string) (*models.RateLimitUsageResponse, error) {
+	current := l.policies.Load()
+	policies := make([]*Policy, 0, len(current.routes)+1)
+	if current.defaultPolicy != nil {
+		policies = append(policies, current.defaultPolicy)
+	}
+	for _, policy := range current.routes {
+		if policy != nil {
+			policies = append(policies, policy)
+		}
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		// The default policy first, then by route
+		if policies[i].Name == DefaultPolicy || policies[j].Name == DefaultPolicy {
+			return policies[i].Name == DefaultPolicy
+		}
+		return policies[i].Name < policies[j].Name
+	})
+
+	now := l.now()
+	response := &models.RateLimitUsageResponse{
+		Client:   client,
+		Policies: make([]models.RateLimitUsage, 0, len(policies)),
+	}
+	for _, policy := range policies {
+		usage, err := l.store.Usage(ctx, storeKey(policy, client), policy, now)
+		if err != nil {
+			return nil, err
+		}
+
+		report := models.RateLimitUsage{
+			Policy:    policy.Name,
+			Limit:     policy.Limit,
+			Period:    policy.Period.String(),
+			Burst:     policy.Burst,
+			Remaining: int(usage.Tokens),
+		}
+		if policy.DailyQuota > 0 {
+			remaining := policy.DailyQuota - usage.QuotaUsed
+			if remaining < 0 {
+				remaining = 0
+			}
+			report.DailyQuota = &models.RateLimitQuota{
+				Limit:     policy.DailyQuota,
+				Used:      usage.QuotaUsed,
+				Remaining: remaining,
+				ResetsAt:  nextDay(now),
+			}
+		}
+		response.Policies = append(response.Policies, report)
+	}
+	return response, nil
+}
+
+// storeKey separates the buckets of each policy
+func storeKey(policy *Policy, client string) string {
+	return policy.Name + "|" + client
+}
+
+// day returns the UTC day of t, which quotas are counted by
+func day(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// nextDay returns the start of the UTC day after t, when quotas reset
+func nextDay(t time.Time) time.Time {
+	year, month, dayOfMonth := t.UTC().Date()
+	return time.Date(year, month, dayOfMonth+1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"transaction-api/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(now time.Time) *Limiter {
+	limiter := New(config.RateLimitConfig{
+		Default: config.RateLimitPolicy{Limit: 60, Period: time.Minute},
+		Routes: map[string]config.RateLimitPolicy{
+			"POST /transactions":     {Limit: 10, Period: time.Minute, Burst: 2, DailyQuota: 100},
+			"GET /dashboard/summary": {},
+		},
+	}, NewMemoryStore())
+	limiter.now = func() time.Time { return now }
+	return limiter
+}
+
+func TestLimiterPolicy(t *testing.T) {
+	limiter := newTestLimiter(time.Now())
+
+	policy := limiter.Policy("POST", "/transactions")
+	require.NotNil(t, policy)
+	assert.Equal(t, "POST /transactions", policy.Name)
+	assert.Equal(t, 2, policy.Burst)
+	assert.InDelta(t, 10.0/60, policy.Rate, 1e-9)
+	assert.Equal(t, "10;w=60;burst=2;quota=100", policy.Header())
+
+	policy = limiter.Policy("GET", "/transactions")
+	require.NotNil(t, policy)
+	assert.Equal(t, DefaultPolicy, policy.Name)
+	assert.Equal(t, 60, policy.Burst)
+	assert.Equal(t, "60;w=60", policy.Header())
+
+	// A disabled route policy overrides the default
+	assert.Nil(t, limiter.Policy("GET", "/dashboard/summary"))
+
+	assert.Nil(t, New(config.RateLimitConfig{}, NewMemoryStore()).Policy("GET", "/transactions"))
+}
+
+func TestLimiterUpdate(t *testing.T) {
+	now := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(now)
+	ctx := context.Background()
+
+	policy := limiter.Policy("POST", "/transactions")
+	_, err := limiter.Allow(ctx, policy, "ip:10.0.0.1")
+	require.NoError(t, err)
+
+	limiter.Update(config.RateLimitConfig{
+		Default: config.RateLimitPolicy{Limit: 5, Period: time.Second},
+		Routes: map[string]config.RateLimitPolicy{
+			"POST /transactions": {Limit: 10, Period: time.Minute, Burst: 2, DailyQuota: 50},
+		},
+	})
+
+	assert.Equal(t, "5;w=1", limiter.Policy("GET", "/transactions").Header())
+	// The route that was disabled now falls back to the default policy
+	require.NotNil(t, limiter.Policy("GET", "/dashboard/summary"))
+	assert.Equal(t, DefaultPolicy, limiter.Policy("GET", "/dashboard/summary").Name)
+
+	// The bucket and quota of the client survive the update
+	decision, err := limiter.Allow(ctx, limiter.Policy("POST", "/transactions"), "ip:10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+	assert.Equal(t, 0, decision.Remaining)
+	assert.Equal(t, int64(2), decision.QuotaUsed)
+}
+
+func TestLimiterUsage(t *testing.T) {
+	now := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(now)
+	ctx := context.Background()
+
+	policy := limiter.Policy("POST", "/transactions")
+	for i := 0; i < 3; i++ {
+		_, err := limiter.Allow(ctx, policy, "ip:10.0.0.1")
+		require.NoError(t, err)
+	}
+
+	usage, err := limiter.Usage(ctx, "ip:10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, "ip:10.0.0.1", usage.Client)
+	require.Len(t, usage.Policies, 2)
+
+	assert.Equal(t, DefaultPolicy, usage.Policies[0].Policy)
+	assert.Equal(t, 60, usage.Policies[0].Remaining)
+	assert.Nil(t, usage.Policies[0].DailyQuota)
+
+	transactions := usage.Policies[1]
+	assert.Equal(t, "POST /transactions", transactions.Policy)
+	assert.Equal(t, 10, transactions.Limit)
+	assert.Equal(t, "1m0s", transactions.Period)
+	assert.Equal(t, 0, transactions.Remaining)
+	require.NotNil(t, transactions.DailyQuota)
+	assert.Equal(t, int64(100), transactions.DailyQuota.Limit)
+	assert.Equal(t, int64(2), transactions.DailyQuota.Used)
+	assert.Equal(t, int64(98), transactions.DailyQuota.Remaining)
+	assert.Equal(t, time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), transactions.DailyQuota.ResetsAt)
+
+	// Clients are counted separately
+	usage, err = limiter.Usage(ctx, "ip:10.0.0.2")
+	require.NoError(t, err)
+	assert.Equal(t, 2, usage.Policies[1].Remaining)
+	assert.Zero(t, usage.Policies[1].DailyQuota.Used)
+}
@@ -0,0 +1,240 @@
+// Package redact masks sensitive data in log entries before they are
+// written: the values of configured fields, and card numbers, emails and
+// phone numbers anywhere in logged strings.
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"transaction-api/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Mask replaces redacted values
+const Mask = "[REDACTED]"
+
+// pattern is sensitive data recognized by its shape
+type pattern struct {
+	re *regexp.Regexp
+	// valid, when set, rejects matches that only look sensitive
+	valid func(match string) bool
+}
+
+// patterns are the patterns that can be configured, by name
+var patterns = map[string]pattern{
+	// Card numbers of 13 to 19 digits, optionally grouped by spaces or
+	// dashes, that pass the Luhn check
+	"pan":   {re: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), valid: luhn},
+	"email": {re: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)},
+	// International numbers, Indonesian mobile numbers and North American
+	// numbers written with separators
+	"phone": {re: regexp.MustCompile(`\+\d{1,3}[ .-]?\(?\d{1,4}\)?(?:[ .-]?\d{2,4}){2,4}\b|\b08\d{8,11}\b|\(\d{3}\) ?\d{3}[ .-]\d{4}\b|\b\d{3}[.-]\d{3}[.-]\d{4}\b`)},
+}
+
+// patternOrder applies card numbers first, so their digits are not taken
+// for phone numbers
+var patternOrder = []string{"pan", "email", "phone"}
+
+// Redactor masks sensitive data according to a RedactionConfig
+type Redactor struct {
+	fields map[string]bool
+	// keyValue matches "field=value" and "field": "value" pairs of sensitive
+	// fields inside strings, such as query strings and JSON in error
+	// messages. The scheme of credentials such as "Bearer" is kept.
+	keyValue *regexp.Regexp
+	// allowed is nil unless only allowed fields are logged
+	allowed  map[string]bool
+	patterns []pattern
+}
+
+// New returns a redactor for cfg
+func New(cfg config.RedactionConfig) *Redactor {
+	r := &Redactor{fields: normalizedSet(cfg.Fields)}
+	if len(cfg.Fields) > 0 {
+		names := make([]string, len(cfg.Fields))
+		for i, field := range cfg.Fields {
+			// Separators are optional, as in normalize
+			names[i] = separators.ReplaceAllString(regexp.QuoteMeta(field), `[_.-]?`)
+		}
+		r.keyValue = regexp.MustCompile(`(?i)\b((?:` + strings.Join(names, "|") + `)"?\s*[:=]\s*"?(?:(?:Bearer|Basic) +)?)[^"&\s,;}]+`)
+	}
+	if cfg.Mode == "allowlist" {
+		r.allowed = normalizedSet(cfg.AllowedFields)
+	}
+	for _, name := range patternOrder {
+		for _, configured := range cfg.Patterns {
+			if strings.EqualFold(configured, name) {
+				r.patterns = append(r.patterns, patterns[name])
+				break
+			}
+		}
+	}
+	return r
+}
+
+// separators separate the words of field names
+var separators = regexp.MustCompile(`(\\\.|[_-])`)
+
+// normalize makes field names match regardless of case and separators, so
+// card_number, cardNumber and Card-Number are the same field
+func normalize(name string) string {
+	return strings.NewReplacer("_", "", "-", "", ".", "").Replace(strings.ToLower(name))
+}
+
+func normalizedSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[normalize(name)] = true
+	}
+	return set
+}
+
+// sensitive reports whether the value of a field is always masked
+func (r *Redactor) sensitive(field string) bool {
+	return r.fields[normalize(field)]
+}
+
+// Fields returns a copy of fields with sensitive values masked. In allowlist
+// mode fields that are not allowed are masked too.
+func (r *Redactor) Fields(fields logrus.Fields) logrus.Fields {
+	redacted := make(logrus.Fields, len(fields))
+	for field, value := range fields {
+		if r.sensitive(field) || (r.allowed != nil && !r.allowed[normalize(field)]) {
+			redacted[field] = Mask
+			continue
+		}
+		redacted[field] = r.Value(value)
+	}
+	return redacted
+}
+
+// String masks the configured patterns and the values of sensitive
+// key-value pairs in s
+func (r *Redactor) String(s string) string {
+	if r.keyValue != nil {
+		s = r.keyValue.ReplaceAllString(s, "${1}"+Mask)
+	}
+	for _, p := range r.patterns {
+		s = p.re.ReplaceAllStringFunc(s, func(match string) string {
+			if p.valid != nil && !p.valid(match) {
+				return match
+			}
+			return Mask
+		})
+	}
+	return s
+}
+
+// Value returns v with sensitive data masked. Strings are searched for
+// patterns, maps and slices are redacted element by element and other
+// values, such as validation error structs, in their JSON form.
+func (r *Redactor) Value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+		float32, float64, time.Duration, time.Time:
+		return v
+	case string:
+		return r.String(v)
+	case error:
+		return r.String(v.Error())
+	case fmt.Stringer:
+		return r.String(v.String())
+	case logrus.Fields:
+		return r.object(v)
+	case map[string]interface{}:
+		return r.object(v)
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, element := range v {
+			redacted[i] = r.Value(element)
+		}
+		return redacted
+	case []string:
+		redacted := make([]string, len(v))
+		for i, element := range v {
+			redacted[i] = r.String(element)
+		}
+		return redacted
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return r.String(fmt.Sprint(v))
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return r.String(string(data))
+	}
+	return r.Value(decoded)
+}
+
+// object redacts a nested object. Only sensitive fields are masked; the
+// allowlist applies to the top-level fields of an entry.
+func (r *Redactor) object(object map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(object))
+	for field, value := range object {
+		if r.sensitive(field) {
+			redacted[field] = Mask
+			continue
+		}
+		redacted[field] = r.Value(value)
+	}
+	return redacted
+}
+
+// luhn reports whether the digits of number pass the Luhn checksum of card
+// numbers
+func luhn(number string) bool {
+	sum, digits := 0, 0
+	for i := len(number) - 1; i >= 0; i-- {
+		if number[i] < '0' || number[i] > '9' {
+			continue
+		}
+		digit := int(number[i] - '0')
+		if digits%2 == 1 {
+			if digit *= 2; digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		digits++
+	}
+	return digits >= 13 && sum%10 == 0
+}
+
+// Hook is a logrus hook redacting the fields and message of every entry.
+// Its configuration can be replaced while logging.
+type Hook struct {
+	redactor atomic.Pointer[Redactor]
+}
+
+// NewHook returns a hook redacting according to cfg
+func NewHook(cfg config.RedactionConfig) *Hook {
+	h := &Hook{}
+	h.Update(cfg)
+	return h
+}
+
+// Update replaces the configuration of the hook
+func (h *Hook) Update(cfg config.RedactionConfig) {
+	h.redactor.Store(New(cfg))
+}
+
+// Levels implements logrus.Hook
+func (h *Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	r := h.redactor.Load()
+	entry.Data = r.Fields(entry.Data)
+	entry.Message = r.String(entry.Message)
+	return nil
+}
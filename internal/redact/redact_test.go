@@ -0,0 +1,146 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"transaction-api/internal/config"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testConfig = config.RedactionConfig{
+	Mode:          "denylist",
+	Fields:        []string{"password", "card_number", "api_key", "authorization"},
+	Patterns:      []string{"pan", "email", "phone"},
+	AllowedFields: []string{"path", "status_code", "details"},
+}
+
+func TestString(t *testing.T) {
+	r := New(testConfig)
+
+	for input, expected := range map[string]string{
+		"card 4111111111111111 declined":            "card [REDACTED] declined",
+		"card 4111 1111 1111 1111 declined":         "card [REDACTED] declined",
+		"card 5500-0000-0000-0004":                  "card [REDACTED]",
+		"contact budi.santoso@example.co.id today":  "contact [REDACTED] today",
+		"call +62 812-3456-7890":                    "call [REDACTED]",
+		"call 081234567890":                         "call [REDACTED]",
+		"call (415) 555-2671":                       "call [REDACTED]",
+		"/api/v1/users?password=hunter2&page=1":     "/api/v1/users?password=[REDACTED]&page=1",
+		`{"cardNumber": "4111-xxxx", "amount": 10}`: `{"cardNumber": "[REDACTED]", "amount": 10}`,
+		"error: api-key=abc123":                     "error: api-key=[REDACTED]",
+		"Authorization: Bearer abc.def":             "Authorization: Bearer [REDACTED]",
+
+		// Numbers that only look sensitive are kept
+		"transaction 1234567890123456 failed":  "transaction 1234567890123456 failed",
+		"created at 2024-03-10T08:00:00Z":      "created at 2024-03-10T08:00:00Z",
+		"request b48861d7-64a2-4e37-a91c-aaa1": "request b48861d7-64a2-4e37-a91c-aaa1",
+		"amount 150000.50 for user 42":         "amount 150000.50 for user 42",
+		"passwords are fine":                   "passwords are fine",
+	} {
+		assert.Equal(t, expected, r.String(input), input)
+	}
+}
+
+func TestStringPatternsAreConfigurable(t *testing.T) {
+	cfg := testConfig
+	cfg.Patterns = []string{"email"}
+	r := New(cfg)
+
+	assert.Equal(t, "[REDACTED] 4111111111111111", r.String("a@example.com 4111111111111111"))
+}
+
+func TestFields(t *testing.T) {
+	r := New(testConfig)
+
+	type fieldError struct {
+		Field string `json:"field"`
+		Value string `json:"value"`
+	}
+
+	fields := r.Fields(logrus.Fields{
+		"Password":    "hunter2",
+		"path":        "/users?email=a@example.com",
+		"status_code": 400,
+		"latency":     3 * time.Millisecond,
+		"error":       errors.New("invalid card 4111111111111111"),
+		"details": []fieldError{
+			{Field: "email", Value: "a@example.com"},
+		},
+		"body": map[string]interface{}{
+			"card_number": "4111111111111111",
+			"note":        "call 081234567890",
+		},
+	})
+
+	assert.Equal(t, logrus.Fields{
+		"Password":    Mask,
+		"path":        "/users?email=[REDACTED]",
+		"status_code": 400,
+		"latency":     3 * time.Millisecond,
+		"error":       "invalid card [REDACTED]",
+		"details": []interface{}{
+			map[string]interface{}{"field": "email", "value": Mask},
+		},
+		"body": map[string]interface{}{
+			"card_number": Mask,
+			"note":        "call [REDACTED]",
+		},
+	}, fields)
+}
+
+func TestFieldsAllowlist(t *testing.T) {
+	cfg := testConfig
+	cfg.Mode = "allowlist"
+	r := New(cfg)
+
+	fields := r.Fields(logrus.Fields{
+		"path":        "/transactions",
+		"status_code": 201,
+		"user_agent":  "Mozilla/5.0",
+		"details":     map[string]interface{}{"reason": "ok", "password": "hunter2"},
+	})
+
+	assert.Equal(t, logrus.Fields{
+		"path":        "/transactions",
+		"status_code": 201,
+		"user_agent":  Mask,
+		// The allowlist applies to top-level fields only
+		"details": map[string]interface{}{"reason": "ok", "password": Mask},
+	}, fields)
+}
+
+func TestHook(t *testing.T) {
+	var buf bytes.Buffer
+	log := logrus.New()
+	log.SetOutput(&buf)
+	log.SetFormatter(&logrus.JSONFormatter{})
+	hook := NewHook(testConfig)
+	log.AddHook(hook)
+
+	entry := log.WithField("api_key", "secret-key")
+	entry.WithField("user_agent", "curl/8.0").Info("charged a@example.com")
+
+	var logged map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logged))
+	assert.Equal(t, Mask, logged["api_key"])
+	assert.Equal(t, "curl/8.0", logged["user_agent"])
+	assert.Equal(t, "charged [REDACTED]", logged["msg"])
+	// The entry the caller holds is not modified
+	assert.Equal(t, "secret-key", entry.Data["api_key"])
+
+	cfg := testConfig
+	cfg.Mode = "allowlist"
+	hook.Update(cfg)
+
+	buf.Reset()
+	log.WithField("user_agent", "curl/8.0").Info("request")
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logged))
+	assert.Equal(t, Mask, logged["user_agent"])
+}
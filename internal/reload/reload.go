@@ -0,0 +1,94 @@
+// Package reload applies a new configuration to the running server when it
+// receives SIGHUP. Only reloadable settings take effect; changes to the
+// others are rejected until the next restart.
+package reload
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"transaction-api/internal/config"
+	"transaction-api/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Reloader reloads the configuration and hands the reloadable settings to
+// the components registered with OnReload
+type Reloader struct {
+	loader *config.Loader
+
+	// mu serializes reloads
+	mu       sync.Mutex
+	appliers []func(cfg *config.Config)
+	last     atomic.Pointer[models.ConfigReload]
+	now      func() time.Time
+}
+
+// New returns a reloader for the configuration of loader, which must have
+// loaded it already
+func New(loader *config.Loader) *Reloader {
+	return &Reloader{loader: loader, now: time.Now}
+}
+
+// OnReload registers apply to be called with the new configuration after
+// every reload that changed a reloadable setting
+func (r *Reloader) OnReload(apply func(cfg *config.Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.appliers = append(r.appliers, apply)
+}
+
+// Reload loads the configuration again and applies it. An invalid
+// configuration changes nothing.
+func (r *Reloader) Reload() *models.ConfigReload {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := &models.ConfigReload{
+		ReloadedAt: r.now().UTC(),
+		Status:     models.ConfigReloadApplied,
+		Applied:    []string{},
+		Rejected:   []string{},
+	}
+	defer r.last.Store(result)
+
+	cfg, changes, err := r.loader.Reload()
+	if err != nil {
+		result.Status = models.ConfigReloadFailed
+		var validationErr *config.ValidationError
+		if errors.As(err, &validationErr) {
+			result.Invalid = validationErr.Settings
+		}
+		logrus.WithError(err).Error("Configuration reload failed, keeping the current configuration")
+		return result
+	}
+
+	for _, change := range changes {
+		if change.Reloadable {
+			result.Applied = append(result.Applied, change.Setting)
+			continue
+		}
+		result.Rejected = append(result.Rejected, change.Setting)
+		logrus.WithField("setting", change.Setting).Warn("Setting cannot change at runtime, restart to apply it")
+	}
+
+	if len(result.Applied) > 0 {
+		for _, apply := range r.appliers {
+			apply(cfg)
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"applied":  result.Applied,
+		"rejected": result.Rejected,
+	}).Info("Configuration reloaded")
+	return result
+}
+
+// Last returns the outcome of the last reload, or nil before the first
+func (r *Reloader) Last() *models.ConfigReload {
+	return r.last.Load()
+}
@@ -0,0 +1,82 @@
+package reload
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"transaction-api/internal/config"
+	"transaction-api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestReloader loads content from a config file and returns a reloader
+// for it with the path of the file
+func newTestReloader(t *testing.T, content string) (*Reloader, string) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	t.Setenv("CONFIG_FILE", path)
+
+	loader := config.NewLoader()
+	_, err := loader.Load()
+	require.NoError(t, err)
+
+	reloader := New(loader)
+	reloader.now = func() time.Time { return time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC) }
+	return reloader, path
+}
+
+func TestReload(t *testing.T) {
+	reloader, path := newTestReloader(t, "log:\n  level: info\n")
+	assert.Nil(t, reloader.Last())
+
+	var applied []*config.Config
+	reloader.OnReload(func(cfg *config.Config) { applied = append(applied, cfg) })
+
+	// Nothing changed, so nothing is applied
+	result := reloader.Reload()
+	assert.Equal(t, models.ConfigReloadApplied, result.Status)
+	assert.Empty(t, result.Applied)
+	assert.Empty(t, applied)
+
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: debug\nserver:\n  port: \"9090\"\n"), 0o600))
+	result = reloader.Reload()
+	assert.Equal(t, &models.ConfigReload{
+		ReloadedAt: time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC),
+		Status:     models.ConfigReloadApplied,
+		Applied:    []string{"LOG_LEVEL"},
+		Rejected:   []string{"SERVER_PORT"},
+	}, result)
+	assert.Same(t, result, reloader.Last())
+
+	require.Len(t, applied, 1)
+	assert.Equal(t, "debug", applied[0].Log.Level)
+	assert.Equal(t, "8080", applied[0].Server.Port)
+}
+
+func TestReloadInvalidConfiguration(t *testing.T) {
+	reloader, path := newTestReloader(t, "log:\n  level: info\n")
+
+	called := false
+	reloader.OnReload(func(*config.Config) { called = true })
+
+	secretFile := filepath.Join(t.TempDir(), "missing-password")
+	t.Setenv("DB_PASSWORD_FILE", secretFile)
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: loud\n"), 0o600))
+	result := reloader.Reload()
+	assert.Equal(t, models.ConfigReloadFailed, result.Status)
+	assert.Empty(t, result.Applied)
+	assert.False(t, called)
+
+	// Only the names of the invalid settings are reported, not their values
+	// or the files they come from
+	assert.Equal(t, []string{"DB_PASSWORD", "LOG_LEVEL"}, result.Invalid)
+	data, err := json.Marshal(result)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "loud")
+	assert.NotContains(t, string(data), secretFile)
+}
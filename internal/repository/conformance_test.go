@@ -0,0 +1,507 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"transaction-api/internal/audit"
+	"transaction-api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTransactionRepository runs the conformance suite every
+// TransactionRepository implementation must pass. newRepo returns an empty
+// repository.
+func testTransactionRepository(t *testing.T, newRepo func(t *testing.T) TransactionRepository) {
+	base := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+
+	// seed stores transactions created an hour apart, starting at base
+	seed := func(t *testing.T, repo TransactionRepository, transactions ...models.Transaction) []models.Transaction {
+		for i := range transactions {
+			if transactions[i].CreatedAt.IsZero() {
+				transactions[i].CreatedAt = base.Add(time.Duration(i) * time.Hour)
+			}
+			require.NoError(t, repo.Create(context.Background(), &transactions[i]))
+		}
+		return transactions
+	}
+
+	fixtures := func() []models.Transaction {
+		return []models.Transaction{
+			{UserID: 1, Amount: 100, Status: models.StatusSuccess},
+			{UserID: 1, Amount: 50, Status: models.StatusFailed, FailureCode: "insufficient_funds"},
+			{UserID: 2, Amount: 300, Status: models.StatusSuccess},
+			{UserID: 2, Amount: 25, Status: models.StatusPending},
+			{UserID: 3, Amount: 10, Status: models.StatusFailed},
+			{UserID: 3, Amount: 200, Status: models.StatusSuccess, CreatedAt: base.Add(26 * time.Hour)},
+		}
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		transaction := &models.Transaction{UserID: 7, Amount: 42.5}
+		require.NoError(t, repo.Create(ctx, transaction))
+		assert.NotZero(t, transaction.ID)
+		assert.False(t, transaction.CreatedAt.IsZero())
+		assert.Equal(t, models.StatusPending, transaction.Status)
+
+		found, err := repo.GetByID(ctx, transaction.ID)
+		require.NoError(t, err)
+		assert.Equal(t, transaction.ID, found.ID)
+		assert.Equal(t, uint(7), found.UserID)
+		assert.Equal(t, 42.5, found.Amount)
+		assert.Equal(t, models.StatusPending, found.Status)
+
+		other := &models.Transaction{UserID: 8, Amount: 1}
+		require.NoError(t, repo.Create(ctx, other))
+		assert.NotEqual(t, transaction.ID, other.ID)
+
+		_, err = repo.GetByID(ctx, 9999)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("CreateDuplicate", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		transaction := &models.Transaction{UserID: 1, Amount: 10, Status: models.StatusPending}
+		require.NoError(t, repo.Create(ctx, transaction))
+
+		duplicate := &models.Transaction{ID: transaction.ID, UserID: 2, Amount: 20, Status: models.StatusPending}
+		assert.ErrorIs(t, repo.Create(ctx, duplicate), ErrDuplicate)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		seeded := seed(t, repo, fixtures()...)
+
+		transactions, total, err := repo.List(ctx, TransactionFilter{}, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(6), total)
+		require.Len(t, transactions, 6)
+		// Newest first
+		assert.Equal(t, seeded[5].ID, transactions[0].ID)
+		assert.Equal(t, seeded[4].ID, transactions[1].ID)
+		assert.Equal(t, seeded[0].ID, transactions[5].ID)
+
+		// Pagination
+		transactions, total, err = repo.List(ctx, TransactionFilter{}, 2, 2)
+		require.NoError(t, err)
+		assert.Equal(t, int64(6), total)
+		require.Len(t, transactions, 2)
+		assert.Equal(t, seeded[3].ID, transactions[0].ID)
+		assert.Equal(t, seeded[2].ID, transactions[1].ID)
+
+		transactions, total, err = repo.List(ctx, TransactionFilter{}, 10, 2)
+		require.NoError(t, err)
+		assert.Equal(t, int64(6), total)
+		assert.Empty(t, transactions)
+
+		// Filters
+		tests := []struct {
+			name   string
+			filter TransactionFilter
+			ids    []uint
+		}{
+			{"user", TransactionFilter{UserID: 2}, []uint{seeded[3].ID, seeded[2].ID}},
+			{"status", TransactionFilter{Status: models.StatusFailed}, []uint{seeded[4].ID, seeded[1].ID}},
+			{"user and status", TransactionFilter{UserID: 1, Status: models.StatusSuccess}, []uint{seeded[0].ID}},
+			{"range", createdIn(base.Add(time.Hour), base.Add(3*time.Hour)), []uint{seeded[2].ID, seeded[1].ID}},
+			{"from", TransactionFilter{From: timePtr(base.Add(4 * time.Hour))}, []uint{seeded[5].ID, seeded[4].ID}},
+			{"to", TransactionFilter{To: timePtr(base.Add(time.Hour))}, []uint{seeded[0].ID}},
+			{"no match", TransactionFilter{UserID: 99}, []uint{}},
+		}
+		for _, tt := range tests {
+			transactions, total, err := repo.List(ctx, tt.filter, 0, 10)
+			require.NoError(t, err, tt.name)
+			assert.Equal(t, int64(len(tt.ids)), total, tt.name)
+			ids := make([]uint, 0, len(transactions))
+			for _, transaction := range transactions {
+				ids = append(ids, transaction.ID)
+			}
+			assert.Equal(t, tt.ids, ids, tt.name)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		seeded := seed(t, repo, models.Transaction{UserID: 1, Amount: 10, Status: models.StatusPending})
+
+		updated, err := repo.Update(ctx, seeded[0].ID, func(transaction *models.Transaction) error {
+			assert.Equal(t, models.StatusPending, transaction.Status)
+			transaction.Status = models.StatusFailed
+			transaction.FailureCode = "timeout"
+			transaction.FailureMessage = "gateway timed out"
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, seeded[0].ID, updated.ID)
+		assert.Equal(t, models.StatusFailed, updated.Status)
+
+		found, err := repo.GetByID(ctx, seeded[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusFailed, found.Status)
+		assert.Equal(t, "timeout", found.FailureCode)
+		assert.Equal(t, "gateway timed out", found.FailureMessage)
+		assert.Equal(t, 10.0, found.Amount)
+
+		// An error from the update function is returned and nothing is stored
+		errRejected := errors.New("rejected")
+		_, err = repo.Update(ctx, seeded[0].ID, func(transaction *models.Transaction) error {
+			transaction.Status = models.StatusSuccess
+			return errRejected
+		})
+		assert.Equal(t, errRejected, err)
+
+		found, err = repo.GetByID(ctx, seeded[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusFailed, found.Status)
+
+		_, err = repo.Update(ctx, 9999, func(*models.Transaction) error { return nil })
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		seeded := seed(t, repo,
+			models.Transaction{UserID: 1, Amount: 10, Status: models.StatusSuccess},
+			models.Transaction{UserID: 1, Amount: 20, Status: models.StatusSuccess},
+		)
+
+		require.NoError(t, repo.Delete(ctx, seeded[0].ID))
+
+		// Deleted transactions are invisible
+		_, err := repo.GetByID(ctx, seeded[0].ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.ErrorIs(t, repo.Delete(ctx, seeded[0].ID), ErrNotFound)
+		_, err = repo.Update(ctx, seeded[0].ID, func(*models.Transaction) error { return nil })
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		transactions, total, err := repo.List(ctx, TransactionFilter{}, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		require.Len(t, transactions, 1)
+		assert.Equal(t, seeded[1].ID, transactions[0].ID)
+
+		stats, err := repo.AmountStats(ctx, TransactionFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), stats.Count)
+		assert.Equal(t, 20.0, stats.Sum)
+
+		assert.ErrorIs(t, repo.Delete(ctx, 9999), ErrNotFound)
+	})
+
+	t.Run("CountByStatus", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		seed(t, repo, fixtures()...)
+
+		counts, err := repo.CountByStatus(ctx, TransactionFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, map[models.TransactionStatus]int64{
+			models.StatusSuccess: 3,
+			models.StatusFailed:  2,
+			models.StatusPending: 1,
+		}, counts)
+
+		counts, err = repo.CountByStatus(ctx, TransactionFilter{UserID: 3})
+		require.NoError(t, err)
+		assert.Equal(t, map[models.TransactionStatus]int64{
+			models.StatusSuccess: 1,
+			models.StatusFailed:  1,
+		}, counts)
+
+		counts, err = repo.CountByStatus(ctx, TransactionFilter{UserID: 99})
+		require.NoError(t, err)
+		assert.Empty(t, counts)
+	})
+
+	t.Run("AmountStats", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		seed(t, repo, fixtures()...)
+
+		stats, err := repo.AmountStats(ctx, TransactionFilter{Status: models.StatusSuccess})
+		require.NoError(t, err)
+		assert.Equal(t, AmountStats{Count: 3, Users: 3, Sum: 600, Min: 100, Max: 300}, stats)
+		assert.Equal(t, 200.0, stats.Average())
+		assert.Equal(t, 200.0, stats.AveragePerUser())
+
+		stats, err = repo.AmountStats(ctx, TransactionFilter{UserID: 1})
+		require.NoError(t, err)
+		assert.Equal(t, AmountStats{Count: 2, Users: 1, Sum: 150, Min: 50, Max: 100}, stats)
+		assert.Equal(t, 75.0, stats.Average())
+		assert.Equal(t, 150.0, stats.AveragePerUser())
+
+		stats, err = repo.AmountStats(ctx, TransactionFilter{UserID: 99})
+		require.NoError(t, err)
+		assert.Equal(t, AmountStats{}, stats)
+		assert.Zero(t, stats.Average())
+		assert.Zero(t, stats.AveragePerUser())
+	})
+
+	t.Run("AmountHistogram", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		seed(t, repo, fixtures()...)
+
+		// Amounts: 10, 25, 50, 100, 200, 300
+		counts, err := repo.AmountHistogram(ctx, TransactionFilter{}, []float64{0, 50, 100, 300})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{2, 1, 3}, counts)
+
+		// Amounts outside the bounds are skipped
+		counts, err = repo.AmountHistogram(ctx, TransactionFilter{}, []float64{25, 100})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{3}, counts)
+
+		counts, err = repo.AmountHistogram(ctx, TransactionFilter{Status: models.StatusSuccess}, []float64{0, 150, 300})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1, 2}, counts)
+
+		counts, err = repo.AmountHistogram(ctx, TransactionFilter{UserID: 99}, []float64{0, 1, 2})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{0, 0}, counts)
+
+		_, err = repo.AmountHistogram(ctx, TransactionFilter{}, []float64{1})
+		assert.Error(t, err)
+	})
+
+	t.Run("AmountAt", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		seed(t, repo, fixtures()...)
+
+		amount, ok, err := repo.AmountAt(ctx, TransactionFilter{}, 0)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, 10.0, amount)
+
+		amount, ok, err = repo.AmountAt(ctx, TransactionFilter{}, 3)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, 100.0, amount)
+
+		amount, ok, err = repo.AmountAt(ctx, TransactionFilter{Status: models.StatusSuccess}, 2)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, 300.0, amount)
+
+		_, ok, err = repo.AmountAt(ctx, TransactionFilter{}, 6)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("UserTotals", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		seed(t, repo, fixtures()...)
+
+		users, err := repo.UserTotals(ctx, TransactionFilter{}, models.TopUsersByAmount, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []models.TopUser{
+			{UserID: 2, TransactionCount: 2, TotalAmount: 325},
+			{UserID: 3, TransactionCount: 2, TotalAmount: 210},
+			{UserID: 1, TransactionCount: 2, TotalAmount: 150},
+		}, users)
+
+		// Ties are broken by user ID
+		users, err = repo.UserTotals(ctx, TransactionFilter{}, models.TopUsersByCount, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []models.TopUser{
+			{UserID: 1, TransactionCount: 2, TotalAmount: 150},
+			{UserID: 2, TransactionCount: 2, TotalAmount: 325},
+		}, users)
+
+		users, err = repo.UserTotals(ctx, TransactionFilter{Status: models.StatusFailed}, models.TopUsersByAmount, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []models.TopUser{
+			{UserID: 1, TransactionCount: 1, TotalAmount: 50},
+			{UserID: 3, TransactionCount: 1, TotalAmount: 10},
+		}, users)
+
+		users, err = repo.UserTotals(ctx, TransactionFilter{UserID: 99}, models.TopUsersByAmount, 10)
+		require.NoError(t, err)
+		assert.Empty(t, users)
+
+		_, err = repo.UserTotals(ctx, TransactionFilter{}, models.TopUsersBy("median"), 10)
+		assert.Error(t, err)
+	})
+
+	t.Run("FailureSeries", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		seed(t, repo, fixtures()...)
+
+		series, err := repo.FailureSeries(ctx, TransactionFilter{}, models.IntervalDay)
+		require.NoError(t, err)
+		require.Len(t, series, 2)
+		assert.True(t, base.Truncate(24*time.Hour).Equal(series[0].Start))
+		assert.Equal(t, int64(5), series[0].Total)
+		assert.Equal(t, int64(2), series[0].Failed)
+		assert.Equal(t, map[string]int64{"insufficient_funds": 1, "": 1}, series[0].ByCode)
+		assert.True(t, base.Truncate(24*time.Hour).Add(24*time.Hour).Equal(series[1].Start))
+		assert.Equal(t, int64(1), series[1].Total)
+		assert.Equal(t, int64(0), series[1].Failed)
+		assert.Empty(t, series[1].ByCode)
+
+		series, err = repo.FailureSeries(ctx, createdIn(base, base.Add(3*time.Hour)), models.IntervalHour)
+		require.NoError(t, err)
+		require.Len(t, series, 3)
+		for i, bucket := range series {
+			assert.True(t, base.Add(time.Duration(i)*time.Hour).Equal(bucket.Start))
+			assert.Equal(t, int64(1), bucket.Total)
+		}
+		assert.Equal(t, int64(1), series[1].Failed)
+		assert.Equal(t, map[string]int64{"insufficient_funds": 1}, series[1].ByCode)
+
+		series, err = repo.FailureSeries(ctx, TransactionFilter{UserID: 99}, models.IntervalDay)
+		require.NoError(t, err)
+		assert.Empty(t, series)
+
+		_, err = repo.FailureSeries(ctx, TransactionFilter{}, models.FailureInterval("week"))
+		assert.Error(t, err)
+	})
+
+	t.Run("CreatedAtRange", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		seed(t, repo, fixtures()...)
+
+		first, last, err := repo.CreatedAtRange(ctx, TransactionFilter{UserID: 3})
+		require.NoError(t, err)
+		require.NotNil(t, first)
+		require.NotNil(t, last)
+		assert.True(t, base.Add(4*time.Hour).Equal(*first))
+		assert.True(t, base.Add(26*time.Hour).Equal(*last))
+
+		first, last, err = repo.CreatedAtRange(ctx, TransactionFilter{UserID: 99})
+		require.NoError(t, err)
+		assert.Nil(t, first)
+		assert.Nil(t, last)
+	})
+
+	t.Run("AuditEvents", func(t *testing.T) {
+		repo := newRepo(t)
+		auditRepo, ok := repo.(AuditRepository)
+		require.True(t, ok, "repository must implement AuditRepository")
+		ctx := audit.WithActor(context.Background(), audit.Actor{ID: "alice", IP: "10.0.0.1", RequestID: "req-1"})
+
+		transaction := &models.Transaction{UserID: 1, Amount: 10, Status: models.StatusPending}
+		require.NoError(t, repo.Create(ctx, transaction))
+		_, err := repo.Update(ctx, transaction.ID, func(transaction *models.Transaction) error {
+			transaction.Status = models.StatusSuccess
+			return nil
+		})
+		require.NoError(t, err)
+
+		// A rejected update or a failed create records nothing
+		_, err = repo.Update(ctx, transaction.ID, func(*models.Transaction) error { return errors.New("rejected") })
+		require.Error(t, err)
+		require.Error(t, repo.Create(ctx, &models.Transaction{ID: transaction.ID, UserID: 1, Amount: 1}))
+
+		require.NoError(t, repo.Delete(context.Background(), transaction.ID))
+
+		events, err := auditRepo.AuditEventsAfter(ctx, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, events, 3)
+		for i, action := range []models.AuditAction{models.AuditActionCreate, models.AuditActionUpdate, models.AuditActionDelete} {
+			assert.Equal(t, uint64(i+1), events[i].Sequence)
+			assert.Equal(t, action, events[i].Action)
+			assert.Equal(t, models.AuditEntityTransaction, events[i].EntityType)
+			assert.Equal(t, transaction.ID, events[i].EntityID)
+		}
+
+		assert.Equal(t, "alice", events[0].Actor)
+		assert.Equal(t, "10.0.0.1", events[0].IP)
+		assert.Equal(t, "req-1", events[0].RequestID)
+		assert.Empty(t, events[0].Before)
+		assert.NotEmpty(t, events[0].After)
+		assert.JSONEq(t, `{"status": {"before": "pending", "after": "success"}}`, string(events[1].Changes))
+		assert.Empty(t, events[2].Actor)
+		assert.NotEmpty(t, events[2].Before)
+		assert.Empty(t, events[2].After)
+
+		after, err := auditRepo.AuditEventsAfter(ctx, 2, 10)
+		require.NoError(t, err)
+		require.Len(t, after, 1)
+		assert.Equal(t, uint64(3), after[0].Sequence)
+
+		result, err := audit.Verify(ctx, auditRepo.AuditEventsAfter)
+		require.NoError(t, err)
+		assert.True(t, result.OK(), "%v", result.Problems)
+		assert.Equal(t, int64(3), result.Checked)
+		assert.Equal(t, uint64(3), result.HeadSequence)
+		assert.Equal(t, events[2].Hash, result.HeadHash)
+
+		// Newest first, filtered and paginated
+		listed, total, err := auditRepo.ListAuditEvents(ctx, AuditFilter{}, 0, 2)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		require.Len(t, listed, 2)
+		assert.Equal(t, uint64(3), listed[0].Sequence)
+		assert.Equal(t, uint64(2), listed[1].Sequence)
+
+		listed, total, err = auditRepo.ListAuditEvents(ctx, AuditFilter{Actor: "alice", Action: models.AuditActionUpdate}, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		require.Len(t, listed, 1)
+		assert.Equal(t, uint64(2), listed[0].Sequence)
+
+		listed, total, err = auditRepo.ListAuditEvents(ctx, AuditFilter{EntityID: transaction.ID + 1}, 0, 10)
+		require.NoError(t, err)
+		assert.Zero(t, total)
+		assert.Empty(t, listed)
+	})
+
+	t.Run("WithPrimary", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		seeded := seed(t, repo, models.Transaction{UserID: 1, Amount: 10, Status: models.StatusPending})
+
+		found, err := repo.WithPrimary().GetByID(ctx, seeded[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, seeded[0].ID, found.ID)
+	})
+
+	t.Run("CanceledContext", func(t *testing.T) {
+		repo := newRepo(t)
+		seeded := seed(t, repo, models.Transaction{UserID: 1, Amount: 10, Status: models.StatusPending})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		assert.ErrorIs(t, repo.Create(ctx, &models.Transaction{UserID: 1, Amount: 1}), context.Canceled)
+		_, err := repo.GetByID(ctx, seeded[0].ID)
+		assert.ErrorIs(t, err, context.Canceled)
+		_, _, err = repo.List(ctx, TransactionFilter{}, 0, 10)
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = repo.Update(ctx, seeded[0].ID, func(*models.Transaction) error { return nil })
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.ErrorIs(t, repo.Delete(ctx, seeded[0].ID), context.Canceled)
+		_, err = repo.AmountStats(ctx, TransactionFilter{})
+		assert.ErrorIs(t, err, context.Canceled)
+
+		// Nothing was changed
+		found, err := repo.GetByID(context.Background(), seeded[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusPending, found.Status)
+	})
+}
+
+func createdIn(from, to time.Time) TransactionFilter {
+	return TransactionFilter{From: &from, To: &to}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
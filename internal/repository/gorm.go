@@ -0,0 +1,473 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"transaction-api/internal/audit"
+	"transaction-api/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// GormTransactionRepository stores transactions in a SQL database
+type GormTransactionRepository struct {
+	db *gorm.DB
+}
+
+func NewGormTransactionRepository(db *gorm.DB) *GormTransactionRepository {
+	return &GormTransactionRepository{db: db}
+}
+
+// WithPrimary returns a repository whose reads go to the primary database
+func (r *GormTransactionRepository) WithPrimary() TransactionRepository {
+	return &GormTransactionRepository{db: r.db.Clauses(dbresolver.Write).Session(&gorm.Session{})}
+}
+
+// Create stores a new transaction
+func (r *GormTransactionRepository) Create(ctx context.Context, transaction *models.Transaction) error {
+	original := *transaction
+	return r.mutate(ctx, func(tx *gorm.DB) error {
+		*transaction = original
+		if err := tx.Create(transaction).Error; err != nil {
+			return translateError(err)
+		}
+		return appendAuditEvent(ctx, tx, models.AuditActionCreate, transaction.ID, nil, transaction)
+	})
+}
+
+// GetByID retrieves a transaction by ID
+func (r *GormTransactionRepository) GetByID(ctx context.Context, id uint) (*models.Transaction, error) {
+	var transaction models.Transaction
+	if err := r.db.WithContext(ctx).First(&transaction, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &transaction, nil
+}
+
+// List retrieves a page of transactions, newest first
+func (r *GormTransactionRepository) List(ctx context.Context, filter TransactionFilter, offset, limit int) ([]models.Transaction, int64, error) {
+	db := r.db.WithContext(ctx).Scopes(filterScope(filter))
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var transactions []models.Transaction
+	if err := db.Offset(offset).Limit(limit).Order("created_at DESC, id DESC").Find(&transactions).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return transactions, total, nil
+}
+
+// Update applies update to a transaction and saves it
+func (r *GormTransactionRepository) Update(ctx context.Context, id uint, update func(*models.Transaction) error) (*models.Transaction, error) {
+	var transaction models.Transaction
+	err := r.mutate(ctx, func(tx *gorm.DB) error {
+		transaction = models.Transaction{}
+		if err := tx.First(&transaction, id).Error; err != nil {
+			return translateError(err)
+		}
+		before := transaction
+
+		if err := update(&transaction); err != nil {
+			return err
+		}
+
+		if err := tx.Save(&transaction).Error; err != nil {
+			return translateError(err)
+		}
+		return appendAuditEvent(ctx, tx, models.AuditActionUpdate, id, &before, &transaction)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &transaction, nil
+}
+
+// Delete soft deletes a transaction
+func (r *GormTransactionRepository) Delete(ctx context.Context, id uint) error {
+	return r.mutate(ctx, func(tx *gorm.DB) error {
+		var transaction models.Transaction
+		if err := tx.First(&transaction, id).Error; err != nil {
+			return translateError(err)
+		}
+
+		if err := tx.Delete(&transaction).Error; err != nil {
+			return err
+		}
+		return appendAuditEvent(ctx, tx, models.AuditActionDelete, id, &transaction, nil)
+	})
+}
+
+// maxAuditAttempts bounds the retries of a mutation whose audit event lost
+// the race for the next sequence number to a concurrent mutation
+const maxAuditAttempts = 5
+
+// errAuditConflict is returned when another audit event took the sequence
+var errAuditConflict = errors.New("audit sequence conflict")
+
+// mutate runs fn in a database transaction on the primary, retrying when
+// its audit event conflicts with a concurrent one
+func (r *GormTransactionRepository) mutate(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	var err error
+	for attempt := 0; attempt < maxAuditAttempts; attempt++ {
+		err = r.db.WithContext(ctx).Transaction(fn)
+		if !errors.Is(err, errAuditConflict) {
+			return err
+		}
+	}
+	return err
+}
+
+// appendAuditEvent chains an event recording the change to the latest
+// audit event and stores it in tx
+func appendAuditEvent(ctx context.Context, tx *gorm.DB, action models.AuditAction, id uint, before, after *models.Transaction) error {
+	event, err := audit.NewTransactionEvent(ctx, action, id, before, after)
+	if err != nil {
+		return err
+	}
+
+	var latest []models.AuditEvent
+	if err := tx.Order("sequence DESC").Limit(1).Find(&latest).Error; err != nil {
+		return fmt.Errorf("failed to read latest audit event: %w", err)
+	}
+	var prev *models.AuditEvent
+	if len(latest) > 0 {
+		prev = &latest[0]
+	}
+	audit.Seal(event, prev)
+
+	if err := tx.Create(event).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return fmt.Errorf("%w: %v", errAuditConflict, err)
+		}
+		return fmt.Errorf("failed to store audit event: %w", err)
+	}
+	return nil
+}
+
+// ListAuditEvents retrieves a page of audit events, newest first
+func (r *GormTransactionRepository) ListAuditEvents(ctx context.Context, filter AuditFilter, offset, limit int) ([]models.AuditEvent, int64, error) {
+	db := r.db.WithContext(ctx).Model(&models.AuditEvent{})
+	if filter.EntityID != 0 {
+		db = db.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.Action != "" {
+		db = db.Where("action = ?", filter.Action)
+	}
+	if filter.Actor != "" {
+		db = db.Where("actor = ?", filter.Actor)
+	}
+	if filter.RequestID != "" {
+		db = db.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.From != nil {
+		db = db.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		db = db.Where("created_at < ?", *filter.To)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	events := []models.AuditEvent{}
+	if err := db.Offset(offset).Limit(limit).Order("sequence DESC").Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+// AuditEventsAfter retrieves the audit events following sequence
+func (r *GormTransactionRepository) AuditEventsAfter(ctx context.Context, sequence uint64, limit int) ([]models.AuditEvent, error) {
+	var events []models.AuditEvent
+	if err := r.db.WithContext(ctx).
+		Where("sequence > ?", sequence).
+		Order("sequence ASC").
+		Limit(limit).
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// CountByStatus counts transactions per status
+func (r *GormTransactionRepository) CountByStatus(ctx context.Context, filter TransactionFilter) (map[models.TransactionStatus]int64, error) {
+	var results []struct {
+		Status models.TransactionStatus
+		Count  int64
+	}
+	if err := r.db.WithContext(ctx).Scopes(filterScope(filter)).
+		Select("status, COUNT(*) as count").
+		Group("status").
+		Scan(&results).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[models.TransactionStatus]int64, len(results))
+	for _, result := range results {
+		counts[result.Status] = result.Count
+	}
+	return counts, nil
+}
+
+// AmountStats summarizes transaction amounts
+func (r *GormTransactionRepository) AmountStats(ctx context.Context, filter TransactionFilter) (AmountStats, error) {
+	var stats struct {
+		Count     int64
+		Users     int64
+		SumAmount float64
+		MinAmount float64
+		MaxAmount float64
+	}
+	if err := r.db.WithContext(ctx).Scopes(filterScope(filter)).
+		Select("COUNT(*) as count, COUNT(DISTINCT user_id) as users, SUM(amount) as sum_amount, MIN(amount) as min_amount, MAX(amount) as max_amount").
+		Scan(&stats).Error; err != nil {
+		return AmountStats{}, err
+	}
+
+	return AmountStats{
+		Count: stats.Count,
+		Users: stats.Users,
+		Sum:   stats.SumAmount,
+		Min:   stats.MinAmount,
+		Max:   stats.MaxAmount,
+	}, nil
+}
+
+// AmountHistogram counts amounts per bucket. Rows are assigned to buckets
+// with a portable CASE expression so they are never loaded into memory.
+func (r *GormTransactionRepository) AmountHistogram(ctx context.Context, filter TransactionFilter, bounds []float64) ([]int64, error) {
+	if len(bounds) < 2 {
+		return nil, fmt.Errorf("histogram needs at least two bounds, got %d", len(bounds))
+	}
+
+	var bucketExpr strings.Builder
+	args := make([]interface{}, 0, len(bounds))
+	if len(bounds) > 2 {
+		bucketExpr.WriteString("CASE")
+		for i := 1; i < len(bounds)-1; i++ {
+			fmt.Fprintf(&bucketExpr, " WHEN amount < ? THEN %d", i-1)
+			args = append(args, bounds[i])
+		}
+		fmt.Fprintf(&bucketExpr, " ELSE %d END", len(bounds)-2)
+	} else {
+		bucketExpr.WriteString("0")
+	}
+	bucketExpr.WriteString(" as bucket, COUNT(*) as count")
+
+	var results []struct {
+		Bucket int
+		Count  int64
+	}
+	if err := r.db.WithContext(ctx).Scopes(filterScope(filter)).
+		Select(bucketExpr.String(), args...).
+		Where("amount >= ? AND amount <= ?", bounds[0], bounds[len(bounds)-1]).
+		Group("bucket").
+		Scan(&results).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make([]int64, len(bounds)-1)
+	for _, result := range results {
+		counts[result.Bucket] = result.Count
+	}
+	return counts, nil
+}
+
+// AmountAt returns the amount at offset in ascending order
+func (r *GormTransactionRepository) AmountAt(ctx context.Context, filter TransactionFilter, offset int) (float64, bool, error) {
+	var amounts []float64
+	if err := r.db.WithContext(ctx).Scopes(filterScope(filter)).
+		Order("amount ASC").
+		Offset(offset).
+		Limit(1).
+		Pluck("amount", &amounts).Error; err != nil {
+		return 0, false, err
+	}
+	if len(amounts) == 0 {
+		return 0, false, nil
+	}
+	return amounts[0], true, nil
+}
+
+// UserTotals returns the users with the highest amount or transaction count.
+// Grouping by user_id is served by the user_id index.
+func (r *GormTransactionRepository) UserTotals(ctx context.Context, filter TransactionFilter, by models.TopUsersBy, limit int) ([]models.TopUser, error) {
+	var orderBy string
+	switch by {
+	case models.TopUsersByAmount:
+		orderBy = "total_amount DESC, user_id ASC"
+	case models.TopUsersByCount:
+		orderBy = "transaction_count DESC, user_id ASC"
+	default:
+		return nil, fmt.Errorf("invalid top users metric: %s", by)
+	}
+
+	users := []models.TopUser{}
+	if err := r.db.WithContext(ctx).Scopes(filterScope(filter)).
+		Select("user_id, COUNT(*) as transaction_count, SUM(amount) as total_amount").
+		Group("user_id").
+		Order(orderBy).
+		Limit(limit).
+		Scan(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// FailureSeries counts transactions and failures per time bucket
+func (r *GormTransactionRepository) FailureSeries(ctx context.Context, filter TransactionFilter, interval models.FailureInterval) ([]FailureSeriesBucket, error) {
+	bucketExpr, err := r.timeBucketExpr(interval)
+	if err != nil {
+		return nil, err
+	}
+	db := r.db.WithContext(ctx)
+
+	// Total and failed transactions per bucket
+	var totalResults []struct {
+		Bucket string
+		Total  int64
+		Failed int64
+	}
+	if err := db.Scopes(filterScope(filter)).
+		Select(bucketExpr+" as bucket, COUNT(*) as total, SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) as failed", models.StatusFailed).
+		Group("bucket").
+		Order("bucket ASC").
+		Scan(&totalResults).Error; err != nil {
+		return nil, err
+	}
+
+	series := make([]FailureSeriesBucket, 0, len(totalResults))
+	buckets := make(map[string]*FailureSeriesBucket, len(totalResults))
+	for _, result := range totalResults {
+		start, err := time.ParseInLocation(timeBucketLayout, result.Bucket, time.UTC)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse time bucket %q: %w", result.Bucket, err)
+		}
+		series = append(series, FailureSeriesBucket{
+			Start:  start,
+			Total:  result.Total,
+			Failed: result.Failed,
+			ByCode: make(map[string]int64),
+		})
+	}
+	for i := range series {
+		buckets[series[i].Start.Format(timeBucketLayout)] = &series[i]
+	}
+
+	// Failures per bucket and code
+	var codeResults []struct {
+		Bucket      string
+		FailureCode string
+		Count       int64
+	}
+	failed := filter
+	failed.Status = models.StatusFailed
+	if err := db.Scopes(filterScope(failed)).
+		Select(bucketExpr + " as bucket, failure_code, COUNT(*) as count").
+		Group("bucket, failure_code").
+		Scan(&codeResults).Error; err != nil {
+		return nil, err
+	}
+
+	for _, result := range codeResults {
+		if bucket, ok := buckets[result.Bucket]; ok {
+			bucket.ByCode[result.FailureCode] += result.Count
+		}
+	}
+
+	return series, nil
+}
+
+// CreatedAtRange returns the first and last creation times
+func (r *GormTransactionRepository) CreatedAtRange(ctx context.Context, filter TransactionFilter) (*time.Time, *time.Time, error) {
+	db := r.db.WithContext(ctx)
+
+	var first, last []models.Transaction
+	if err := db.Scopes(filterScope(filter)).Select("created_at").
+		Order("created_at ASC").Limit(1).Find(&first).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(first) == 0 {
+		return nil, nil, nil
+	}
+	if err := db.Scopes(filterScope(filter)).Select("created_at").
+		Order("created_at DESC").Limit(1).Find(&last).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return &first[0].CreatedAt, &last[0].CreatedAt, nil
+}
+
+// timeBucketLayout is the layout of the labels produced by timeBucketExpr
+const timeBucketLayout = "2006-01-02 15:04:05"
+
+// timeBucketExpr returns a SQL expression truncating created_at to the interval
+func (r *GormTransactionRepository) timeBucketExpr(interval models.FailureInterval) (string, error) {
+	var format, postgresFormat string
+	switch interval {
+	case models.IntervalHour:
+		format = "%Y-%m-%d %H:00:00"
+		postgresFormat = "YYYY-MM-DD HH24:00:00"
+	case models.IntervalDay:
+		format = "%Y-%m-%d 00:00:00"
+		postgresFormat = "YYYY-MM-DD 00:00:00"
+	default:
+		return "", fmt.Errorf("invalid interval: %s", interval)
+	}
+
+	switch r.db.Dialector.Name() {
+	case "mysql":
+		return fmt.Sprintf("DATE_FORMAT(created_at, '%s')", format), nil
+	case "postgres":
+		return fmt.Sprintf("to_char(created_at AT TIME ZONE 'UTC', '%s')", postgresFormat), nil
+	case "sqlite":
+		return fmt.Sprintf("strftime('%s', created_at)", format), nil
+	default:
+		return "", fmt.Errorf("unsupported database dialect: %s", r.db.Dialector.Name())
+	}
+}
+
+// filterScope restricts a transaction query by filter
+func filterScope(filter TransactionFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Model(&models.Transaction{})
+		if filter.UserID != 0 {
+			db = db.Where("user_id = ?", filter.UserID)
+		}
+		if filter.Status != "" {
+			db = db.Where("status = ?", filter.Status)
+		}
+		if filter.From != nil {
+			db = db.Where("created_at >= ?", *filter.From)
+		}
+		if filter.To != nil {
+			db = db.Where("created_at < ?", *filter.To)
+		}
+		return db
+	}
+}
+
+// translateError maps GORM errors to the repository errors
+func translateError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return fmt.Errorf("%w: %v", ErrDuplicate, err)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"transaction-api/internal/audit"
+	"transaction-api/internal/models"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newGormTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Silent),
+		TranslateError: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Transaction{}, &models.AuditEvent{}))
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	return db
+}
+
+func TestGormTransactionRepository(t *testing.T) {
+	testTransactionRepository(t, func(t *testing.T) TransactionRepository {
+		return NewGormTransactionRepository(newGormTestDB(t))
+	})
+}
+
+func TestGormAuditTampering(t *testing.T) {
+	setup := func(t *testing.T) (*gorm.DB, *GormTransactionRepository) {
+		db := newGormTestDB(t)
+		repo := NewGormTransactionRepository(db)
+		ctx := context.Background()
+		for i := 0; i < 3; i++ {
+			require.NoError(t, repo.Create(ctx, &models.Transaction{UserID: 1, Amount: float64(i + 1)}))
+		}
+		return db, repo
+	}
+
+	t.Run("Altered", func(t *testing.T) {
+		db, repo := setup(t)
+		require.NoError(t, db.Exec("UPDATE audit_events SET actor = ? WHERE sequence = ?", "mallory", 2).Error)
+
+		result, err := audit.Verify(context.Background(), repo.AuditEventsAfter)
+		require.NoError(t, err)
+		require.Len(t, result.Problems, 1)
+		assert.Equal(t, uint64(2), result.Problems[0].Sequence)
+		assert.Equal(t, "hash does not match the event content", result.Problems[0].Reason)
+	})
+
+	t.Run("Removed", func(t *testing.T) {
+		db, repo := setup(t)
+		require.NoError(t, db.Exec("DELETE FROM audit_events WHERE sequence = ?", 2).Error)
+
+		result, err := audit.Verify(context.Background(), repo.AuditEventsAfter)
+		require.NoError(t, err)
+		assert.False(t, result.OK())
+		assert.Equal(t, int64(2), result.Checked)
+		for _, problem := range result.Problems {
+			assert.Equal(t, uint64(3), problem.Sequence)
+		}
+	})
+}
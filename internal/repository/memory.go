@@ -0,0 +1,476 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"transaction-api/internal/audit"
+	"transaction-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// MemoryTransactionRepository stores transactions in memory. It is safe for
+// concurrent use and matches the behavior of the GORM repository.
+type MemoryTransactionRepository struct {
+	mu           sync.RWMutex
+	transactions map[uint]*models.Transaction
+	nextID       uint
+	auditEvents  []models.AuditEvent
+	now          func() time.Time
+}
+
+func NewMemoryTransactionRepository() *MemoryTransactionRepository {
+	return &MemoryTransactionRepository{
+		transactions: make(map[uint]*models.Transaction),
+		nextID:       1,
+		now:          func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// WithPrimary returns the repository itself since it has no replicas
+func (r *MemoryTransactionRepository) WithPrimary() TransactionRepository {
+	return r
+}
+
+// Create stores a new transaction
+func (r *MemoryTransactionRepository) Create(ctx context.Context, transaction *models.Transaction) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if transaction.ID != 0 {
+		if _, ok := r.transactions[transaction.ID]; ok {
+			return fmt.Errorf("%w: id %d", ErrDuplicate, transaction.ID)
+		}
+	} else {
+		for r.transactions[r.nextID] != nil {
+			r.nextID++
+		}
+		transaction.ID = r.nextID
+	}
+	if transaction.ID >= r.nextID {
+		r.nextID = transaction.ID + 1
+	}
+
+	now := r.now()
+	if transaction.CreatedAt.IsZero() {
+		transaction.CreatedAt = now
+	}
+	if transaction.UpdatedAt.IsZero() {
+		transaction.UpdatedAt = now
+	}
+	if transaction.Status == "" {
+		transaction.Status = models.StatusPending
+	}
+
+	stored := *transaction
+	if err := r.appendAuditEvent(ctx, models.AuditActionCreate, stored.ID, nil, &stored); err != nil {
+		return err
+	}
+	r.transactions[stored.ID] = &stored
+	return nil
+}
+
+// GetByID retrieves a transaction by ID
+func (r *MemoryTransactionRepository) GetByID(ctx context.Context, id uint) (*models.Transaction, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	transaction, ok := r.find(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	result := *transaction
+	return &result, nil
+}
+
+// List retrieves a page of transactions, newest first
+func (r *MemoryTransactionRepository) List(ctx context.Context, filter TransactionFilter, offset, limit int) ([]models.Transaction, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	r.mu.RLock()
+	matches := r.match(filter)
+	r.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if !matches[i].CreatedAt.Equal(matches[j].CreatedAt) {
+			return matches[i].CreatedAt.After(matches[j].CreatedAt)
+		}
+		return matches[i].ID > matches[j].ID
+	})
+
+	total := int64(len(matches))
+	if offset > len(matches) {
+		offset = len(matches)
+	}
+	matches = matches[offset:]
+	if limit >= 0 && limit < len(matches) {
+		matches = matches[:limit]
+	}
+	return matches, total, nil
+}
+
+// Update applies update to a copy of the transaction and stores it
+func (r *MemoryTransactionRepository) Update(ctx context.Context, id uint, update func(*models.Transaction) error) (*models.Transaction, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.find(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	transaction := *stored
+	if err := update(&transaction); err != nil {
+		return nil, err
+	}
+	transaction.ID = id
+	transaction.UpdatedAt = r.now()
+
+	if err := r.appendAuditEvent(ctx, models.AuditActionUpdate, id, stored, &transaction); err != nil {
+		return nil, err
+	}
+	*stored = transaction
+	return &transaction, nil
+}
+
+// Delete soft deletes a transaction
+func (r *MemoryTransactionRepository) Delete(ctx context.Context, id uint) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	transaction, ok := r.find(id)
+	if !ok {
+		return ErrNotFound
+	}
+	if err := r.appendAuditEvent(ctx, models.AuditActionDelete, id, transaction, nil); err != nil {
+		return err
+	}
+	transaction.DeletedAt = gorm.DeletedAt{Time: r.now(), Valid: true}
+	return nil
+}
+
+// appendAuditEvent chains an event recording the change to the latest audit
+// event. The caller must hold the write lock.
+func (r *MemoryTransactionRepository) appendAuditEvent(ctx context.Context, action models.AuditAction, id uint, before, after *models.Transaction) error {
+	event, err := audit.NewTransactionEvent(ctx, action, id, before, after)
+	if err != nil {
+		return err
+	}
+
+	var prev *models.AuditEvent
+	if len(r.auditEvents) > 0 {
+		prev = &r.auditEvents[len(r.auditEvents)-1]
+	}
+	audit.Seal(event, prev)
+
+	event.ID = uint(event.Sequence)
+	r.auditEvents = append(r.auditEvents, *event)
+	return nil
+}
+
+// ListAuditEvents retrieves a page of audit events, newest first
+func (r *MemoryTransactionRepository) ListAuditEvents(ctx context.Context, filter AuditFilter, offset, limit int) ([]models.AuditEvent, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := []models.AuditEvent{}
+	for i := len(r.auditEvents) - 1; i >= 0; i-- {
+		event := r.auditEvents[i]
+		switch {
+		case filter.EntityID != 0 && event.EntityID != filter.EntityID:
+		case filter.Action != "" && event.Action != filter.Action:
+		case filter.Actor != "" && event.Actor != filter.Actor:
+		case filter.RequestID != "" && event.RequestID != filter.RequestID:
+		case filter.From != nil && event.CreatedAt.Before(*filter.From):
+		case filter.To != nil && !event.CreatedAt.Before(*filter.To):
+		default:
+			events = append(events, event)
+		}
+	}
+
+	total := int64(len(events))
+	if offset > len(events) {
+		offset = len(events)
+	}
+	events = events[offset:]
+	if limit >= 0 && limit < len(events) {
+		events = events[:limit]
+	}
+	return events, total, nil
+}
+
+// AuditEventsAfter retrieves the audit events following sequence
+func (r *MemoryTransactionRepository) AuditEventsAfter(ctx context.Context, sequence uint64, limit int) ([]models.AuditEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	start := sort.Search(len(r.auditEvents), func(i int) bool {
+		return r.auditEvents[i].Sequence > sequence
+	})
+	events := r.auditEvents[start:]
+	if limit >= 0 && limit < len(events) {
+		events = events[:limit]
+	}
+	return append([]models.AuditEvent(nil), events...), nil
+}
+
+// CountByStatus counts transactions per status
+func (r *MemoryTransactionRepository) CountByStatus(ctx context.Context, filter TransactionFilter) (map[models.TransactionStatus]int64, error) {
+	matches, err := r.matching(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[models.TransactionStatus]int64)
+	for _, transaction := range matches {
+		counts[transaction.Status]++
+	}
+	return counts, nil
+}
+
+// AmountStats summarizes transaction amounts
+func (r *MemoryTransactionRepository) AmountStats(ctx context.Context, filter TransactionFilter) (AmountStats, error) {
+	matches, err := r.matching(ctx, filter)
+	if err != nil {
+		return AmountStats{}, err
+	}
+
+	var stats AmountStats
+	users := make(map[uint]bool)
+	for i, transaction := range matches {
+		if i == 0 || transaction.Amount < stats.Min {
+			stats.Min = transaction.Amount
+		}
+		if i == 0 || transaction.Amount > stats.Max {
+			stats.Max = transaction.Amount
+		}
+		stats.Sum += transaction.Amount
+		users[transaction.UserID] = true
+	}
+	stats.Count = int64(len(matches))
+	stats.Users = int64(len(users))
+	return stats, nil
+}
+
+// AmountHistogram counts amounts per bucket
+func (r *MemoryTransactionRepository) AmountHistogram(ctx context.Context, filter TransactionFilter, bounds []float64) ([]int64, error) {
+	if len(bounds) < 2 {
+		return nil, fmt.Errorf("histogram needs at least two bounds, got %d", len(bounds))
+	}
+
+	matches, err := r.matching(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]int64, len(bounds)-1)
+	for _, transaction := range matches {
+		if transaction.Amount < bounds[0] || transaction.Amount > bounds[len(bounds)-1] {
+			continue
+		}
+		bucket := sort.Search(len(bounds)-2, func(i int) bool {
+			return transaction.Amount < bounds[i+1]
+		})
+		counts[bucket]++
+	}
+	return counts, nil
+}
+
+// AmountAt returns the amount at offset in ascending order
+func (r *MemoryTransactionRepository) AmountAt(ctx context.Context, filter TransactionFilter, offset int) (float64, bool, error) {
+	matches, err := r.matching(ctx, filter)
+	if err != nil {
+		return 0, false, err
+	}
+	if offset < 0 || offset >= len(matches) {
+		return 0, false, nil
+	}
+
+	amounts := make([]float64, len(matches))
+	for i, transaction := range matches {
+		amounts[i] = transaction.Amount
+	}
+	sort.Float64s(amounts)
+	return amounts[offset], true, nil
+}
+
+// UserTotals returns the users with the highest amount or transaction count
+func (r *MemoryTransactionRepository) UserTotals(ctx context.Context, filter TransactionFilter, by models.TopUsersBy, limit int) ([]models.TopUser, error) {
+	var less func(a, b *models.TopUser) bool
+	switch by {
+	case models.TopUsersByAmount:
+		less = func(a, b *models.TopUser) bool { return a.TotalAmount > b.TotalAmount }
+	case models.TopUsersByCount:
+		less = func(a, b *models.TopUser) bool { return a.TransactionCount > b.TransactionCount }
+	default:
+		return nil, fmt.Errorf("invalid top users metric: %s", by)
+	}
+
+	matches, err := r.matching(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[uint]*models.TopUser)
+	for _, transaction := range matches {
+		user, ok := totals[transaction.UserID]
+		if !ok {
+			user = &models.TopUser{UserID: transaction.UserID}
+			totals[transaction.UserID] = user
+		}
+		user.TransactionCount++
+		user.TotalAmount += transaction.Amount
+	}
+
+	users := make([]models.TopUser, 0, len(totals))
+	for _, user := range totals {
+		users = append(users, *user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if less(&users[i], &users[j]) {
+			return true
+		}
+		if less(&users[j], &users[i]) {
+			return false
+		}
+		return users[i].UserID < users[j].UserID
+	})
+
+	if limit >= 0 && limit < len(users) {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
+// FailureSeries counts transactions and failures per time bucket
+func (r *MemoryTransactionRepository) FailureSeries(ctx context.Context, filter TransactionFilter, interval models.FailureInterval) ([]FailureSeriesBucket, error) {
+	var size time.Duration
+	switch interval {
+	case models.IntervalHour:
+		size = time.Hour
+	case models.IntervalDay:
+		size = 24 * time.Hour
+	default:
+		return nil, fmt.Errorf("invalid interval: %s", interval)
+	}
+
+	matches, err := r.matching(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make(map[time.Time]*FailureSeriesBucket)
+	for _, transaction := range matches {
+		start := transaction.CreatedAt.UTC().Truncate(size)
+		bucket, ok := buckets[start]
+		if !ok {
+			bucket = &FailureSeriesBucket{Start: start, ByCode: make(map[string]int64)}
+			buckets[start] = bucket
+		}
+		bucket.Total++
+		if transaction.Status == models.StatusFailed {
+			bucket.Failed++
+			bucket.ByCode[transaction.FailureCode]++
+		}
+	}
+
+	series := make([]FailureSeriesBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		series = append(series, *bucket)
+	}
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].Start.Before(series[j].Start)
+	})
+	return series, nil
+}
+
+// CreatedAtRange returns the first and last creation times
+func (r *MemoryTransactionRepository) CreatedAtRange(ctx context.Context, filter TransactionFilter) (*time.Time, *time.Time, error) {
+	matches, err := r.matching(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(matches) == 0 {
+		return nil, nil, nil
+	}
+
+	first, last := matches[0].CreatedAt, matches[0].CreatedAt
+	for _, transaction := range matches[1:] {
+		if transaction.CreatedAt.Before(first) {
+			first = transaction.CreatedAt
+		}
+		if transaction.CreatedAt.After(last) {
+			last = transaction.CreatedAt
+		}
+	}
+	return &first, &last, nil
+}
+
+// matching returns copies of the transactions matching filter
+func (r *MemoryTransactionRepository) matching(ctx context.Context, filter TransactionFilter) ([]models.Transaction, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.match(filter), nil
+}
+
+// match returns copies of the transactions matching filter. The caller
+// must hold the lock.
+func (r *MemoryTransactionRepository) match(filter TransactionFilter) []models.Transaction {
+	matches := make([]models.Transaction, 0, len(r.transactions))
+	for _, transaction := range r.transactions {
+		switch {
+		case transaction.DeletedAt.Valid:
+		case filter.UserID != 0 && transaction.UserID != filter.UserID:
+		case filter.Status != "" && transaction.Status != filter.Status:
+		case filter.From != nil && transaction.CreatedAt.Before(*filter.From):
+		case filter.To != nil && !transaction.CreatedAt.Before(*filter.To):
+		default:
+			matches = append(matches, *transaction)
+		}
+	}
+	return matches
+}
+
+// find returns a stored transaction that is not deleted. The caller must
+// hold the lock.
+func (r *MemoryTransactionRepository) find(id uint) (*models.Transaction, bool) {
+	transaction, ok := r.transactions[id]
+	if !ok || transaction.DeletedAt.Valid {
+		return nil, false
+	}
+	return transaction, true
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"transaction-api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryTransactionRepository(t *testing.T) {
+	testTransactionRepository(t, func(t *testing.T) TransactionRepository {
+		return NewMemoryTransactionRepository()
+	})
+}
+
+func TestMemoryTransactionRepositoryConcurrency(t *testing.T) {
+	repo := NewMemoryTransactionRepository()
+	ctx := context.Background()
+
+	const workers = 20
+	var wg sync.WaitGroup
+	ids := make(chan uint, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(userID uint) {
+			defer wg.Done()
+			transaction := &models.Transaction{UserID: userID, Amount: 10}
+			if !assert.NoError(t, repo.Create(ctx, transaction)) {
+				return
+			}
+			ids <- transaction.ID
+
+			_, err := repo.Update(ctx, transaction.ID, func(transaction *models.Transaction) error {
+				transaction.Amount += 5
+				return nil
+			})
+			assert.NoError(t, err)
+			_, _, err = repo.List(ctx, TransactionFilter{}, 0, 5)
+			assert.NoError(t, err)
+		}(uint(i + 1))
+	}
+	wg.Wait()
+	close(ids)
+
+	// Every transaction got a unique ID
+	seen := make(map[uint]bool)
+	for id := range ids {
+		assert.False(t, seen[id], "duplicate id %d", id)
+		seen[id] = true
+	}
+	assert.Len(t, seen, workers)
+
+	stats, err := repo.AmountStats(ctx, TransactionFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(workers), stats.Count)
+	assert.Equal(t, float64(workers*15), stats.Sum)
+}
+
+func TestMemoryTransactionRepositoryReturnsCopies(t *testing.T) {
+	repo := NewMemoryTransactionRepository()
+	ctx := context.Background()
+
+	transaction := &models.Transaction{UserID: 1, Amount: 10}
+	require.NoError(t, repo.Create(ctx, transaction))
+	transaction.Amount = 99
+
+	found, err := repo.GetByID(ctx, transaction.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, found.Amount)
+
+	found.Amount = 99
+	found, err = repo.GetByID(ctx, transaction.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, found.Amount)
+}
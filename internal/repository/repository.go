@@ -0,0 +1,133 @@
+// Package repository stores transactions behind the TransactionRepository
+// interface, with a GORM implementation for SQL databases and an in-memory
+// implementation for tests and local development.
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"transaction-api/internal/models"
+)
+
+var (
+	// ErrNotFound is returned when a transaction does not exist or is deleted
+	ErrNotFound = errors.New("transaction not found")
+	// ErrDuplicate is returned when a transaction conflicts with a stored one
+	ErrDuplicate = errors.New("transaction already exists")
+)
+
+// TransactionRepository stores transactions and computes the aggregates the
+// service reports on. Deleted transactions are invisible to every method.
+// Every create, update and delete appends an audit event, attributed to the
+// actor in the context, atomically with the change.
+type TransactionRepository interface {
+	// Create stores a new transaction and assigns its ID and timestamps.
+	// A zero CreatedAt is set to the current time.
+	Create(ctx context.Context, transaction *models.Transaction) error
+	// GetByID returns a transaction or ErrNotFound
+	GetByID(ctx context.Context, id uint) (*models.Transaction, error)
+	// List returns a page of matching transactions, newest first, and the
+	// total number of matches
+	List(ctx context.Context, filter TransactionFilter, offset, limit int) ([]models.Transaction, int64, error)
+	// Update applies update to a transaction and stores the result. An error
+	// from update is returned as is and leaves the transaction unchanged.
+	Update(ctx context.Context, id uint, update func(*models.Transaction) error) (*models.Transaction, error)
+	// Delete soft deletes a transaction or returns ErrNotFound
+	Delete(ctx context.Context, id uint) error
+
+	// CountByStatus counts matching transactions per status
+	CountByStatus(ctx context.Context, filter TransactionFilter) (map[models.TransactionStatus]int64, error)
+	// AmountStats summarizes the amounts of matching transactions
+	AmountStats(ctx context.Context, filter TransactionFilter) (AmountStats, error)
+	// AmountHistogram counts the matching amounts within [bounds[0],
+	// bounds[n]] in the n buckets between consecutive bounds. Buckets
+	// include their lower bound; the last one also includes its upper bound.
+	AmountHistogram(ctx context.Context, filter TransactionFilter, bounds []float64) ([]int64, error)
+	// AmountAt returns the matching amount at offset in ascending order, and
+	// false when there are not that many matches
+	AmountAt(ctx context.Context, filter TransactionFilter, offset int) (float64, bool, error)
+	// UserTotals returns up to limit users ordered by total amount or
+	// transaction count, descending, then by user ID. Share is left zero.
+	UserTotals(ctx context.Context, filter TransactionFilter, by models.TopUsersBy, limit int) ([]models.TopUser, error)
+	// FailureSeries counts matching transactions and failures per failure
+	// code in UTC hour or day buckets, oldest first. Buckets without
+	// transactions are omitted.
+	FailureSeries(ctx context.Context, filter TransactionFilter, interval models.FailureInterval) ([]FailureSeriesBucket, error)
+	// CreatedAtRange returns the creation times of the first and last
+	// matching transactions, or nil when nothing matches
+	CreatedAtRange(ctx context.Context, filter TransactionFilter) (first, last *time.Time, err error)
+
+	// WithPrimary returns a repository whose reads go to the primary
+	// database instead of a read replica. Repositories without replicas
+	// return themselves.
+	WithPrimary() TransactionRepository
+}
+
+// AuditRepository reads the audit events recorded by a TransactionRepository
+type AuditRepository interface {
+	// ListAuditEvents returns a page of matching events, newest first, and
+	// the total number of matches
+	ListAuditEvents(ctx context.Context, filter AuditFilter, offset, limit int) ([]models.AuditEvent, int64, error)
+	// AuditEventsAfter returns up to limit events with a sequence greater
+	// than sequence, in ascending sequence order
+	AuditEventsAfter(ctx context.Context, sequence uint64, limit int) ([]models.AuditEvent, error)
+}
+
+// AuditFilter restricts the audit events a query covers. Zero fields do not
+// restrict.
+type AuditFilter struct {
+	EntityID  uint
+	Action    models.AuditAction
+	Actor     string
+	RequestID string
+	// From and To restrict event times to [From, To)
+	From *time.Time
+	To   *time.Time
+}
+
+// TransactionFilter restricts the transactions a query covers. Zero fields
+// do not restrict.
+type TransactionFilter struct {
+	UserID uint
+	Status models.TransactionStatus
+	// From and To restrict creation times to [From, To)
+	From *time.Time
+	To   *time.Time
+}
+
+// AmountStats summarizes the amounts of a set of transactions
+type AmountStats struct {
+	Count int64
+	Users int64
+	Sum   float64
+	Min   float64
+	Max   float64
+}
+
+// Average returns the mean amount, or zero for an empty set
+func (s AmountStats) Average() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / float64(s.Count)
+}
+
+// AveragePerUser returns the mean of each user's total amount
+func (s AmountStats) AveragePerUser() float64 {
+	if s.Users == 0 {
+		return 0
+	}
+	return s.Sum / float64(s.Users)
+}
+
+// FailureSeriesBucket counts transactions and failures in a time bucket
+type FailureSeriesBucket struct {
+	Start  time.Time
+	Total  int64
+	Failed int64
+	// ByCode counts failures per failure code, with "" for failures
+	// recorded without one
+	ByCode map[string]int64
+}
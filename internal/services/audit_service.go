@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"math"
+
+	"transaction-api/internal/audit"
+	"transaction-api/internal/models"
+	"transaction-api/internal/repository"
+	"transaction-api/internal/tracing"
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+type AuditService struct {
+	repo     repository.AuditRepository
+	timeouts Timeouts
+}
+
+// NewAuditService returns a service reading the audit events in repo
+func NewAuditService(repo repository.AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// SetTimeouts replaces the per-operation database timeouts
+func (s *AuditService) SetTimeouts(timeouts Timeouts) {
+	s.timeouts = timeouts
+}
+
+// GetAuditEvents retrieves audit events with filtering and pagination
+func (s *AuditService) GetAuditEvents(ctx context.Context, query *models.AuditQuery) (_ *models.AuditEventResponse, err error) {
+	ctx, span := tracing.Start(ctx, "AuditService.GetAuditEvents")
+	defer tracing.End(span, &err)
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	if query.Limit <= 0 {
+		query.Limit = defaultAuditLimit
+	}
+	if query.Limit > maxAuditLimit {
+		query.Limit = maxAuditLimit
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+
+	switch query.Action {
+	case "", models.AuditActionCreate, models.AuditActionUpdate, models.AuditActionDelete:
+	default:
+		return nil, fieldValidationError("action", "oneof", "invalid audit action: {0}", string(query.Action))
+	}
+
+	filter := repository.AuditFilter{
+		EntityID:  query.EntityID,
+		Action:    query.Action,
+		Actor:     query.Actor,
+		RequestID: query.RequestID,
+		From:      query.From,
+		To:        query.To,
+	}
+	events, total, err := s.repo.ListAuditEvents(ctx, filter, (query.Page-1)*query.Limit, query.Limit)
+	if err != nil {
+		return nil, repositoryError(err, "failed to get audit events")
+	}
+
+	return &models.AuditEventResponse{
+		Data:       events,
+		Total:      total,
+		Page:       query.Page,
+		Limit:      query.Limit,
+		TotalPages: int(math.Ceil(float64(total) / float64(query.Limit))),
+	}, nil
+}
+
+// VerifyAuditChain checks the whole audit chain for altered or removed events
+// and against previously recorded heads
+func (s *AuditService) VerifyAuditChain(ctx context.Context, heads ...audit.Head) (_ *audit.VerifyResult, err error) {
+	ctx, span := tracing.Start(ctx, "AuditService.VerifyAuditChain")
+	defer tracing.End(span, &err)
+
+	return audit.Verify(ctx, s.repo.AuditEventsAfter, heads...)
+}
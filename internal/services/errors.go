@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"transaction-api/internal/i18n"
+	"transaction-api/internal/repository"
+)
+
+// Sentinel errors classifying domain failures; match them with errors.Is
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("validation failed")
+)
+
+// Error is a domain error whose message is safe to return to clients. It
+// matches its Kind with errors.Is and unwraps to the underlying cause, which
+// is only meant for logs.
+type Error struct {
+	Kind    error
+	Message string
+	Err     error
+
+	// Key and Params identify the message in the i18n catalogs
+	Key    string
+	Params []string
+
+	// Field and Rule identify the request field and the rule it broke,
+	// for validation errors about a single field
+	Field string
+	Rule  string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is reports whether target is the kind of this error
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// newError builds a domain error from an i18n message key and its parameters
+func newError(kind error, key string, params ...string) *Error {
+	return &Error{Kind: kind, Message: i18n.Format(key, params...), Key: key, Params: params}
+}
+
+func notFoundError(key string, params ...string) error {
+	return newError(ErrNotFound, key, params...)
+}
+
+func validationError(key string, params ...string) error {
+	return newError(ErrValidation, key, params...)
+}
+
+func fieldValidationError(field, rule, key string, params ...string) error {
+	err := newError(ErrValidation, key, params...)
+	err.Field, err.Rule = field, rule
+	return err
+}
+
+// repositoryError classifies a repository error, keeping the driver error as
+// the cause of conflicts and wrapping anything else with what was being done
+func repositoryError(err error, action string) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return notFoundError("transaction not found")
+	case errors.Is(err, repository.ErrDuplicate):
+		conflict := newError(ErrConflict, "transaction already exists")
+		conflict.Err = err
+		return conflict
+	default:
+		return fmt.Errorf("%s: %w", action, err)
+	}
+}
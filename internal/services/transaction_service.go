@@ -1,38 +1,123 @@
 package services
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"math"
+	"strconv"
 	"time"
 
+	"transaction-api/internal/logging"
+	"transaction-api/internal/metrics"
 	"transaction-api/internal/models"
+	"transaction-api/internal/repository"
+	"transaction-api/internal/tracing"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
 type TransactionService struct {
-	db *gorm.DB
+	repo         repository.TransactionRepository
+	failureCodes map[string]bool
+	timeouts     Timeouts
+	metrics      *metrics.Metrics
 }
 
+// Timeouts bounds the duration of database operations by kind. A zero
+// timeout leaves the operation bounded only by the caller's context.
+type Timeouts struct {
+	Read      time.Duration
+	Write     time.Duration
+	Analytics time.Duration
+}
+
+// NewTransactionService returns a service storing transactions in db
 func NewTransactionService(db *gorm.DB) *TransactionService {
-	return &TransactionService{db: db}
+	return NewTransactionServiceWithRepository(repository.NewGormTransactionRepository(db))
+}
+
+// NewTransactionServiceWithRepository returns a service storing transactions in repo
+func NewTransactionServiceWithRepository(repo repository.TransactionRepository) *TransactionService {
+	service := &TransactionService{repo: repo}
+	service.SetFailureCodes(models.DefaultFailureCodes)
+	return service
+}
+
+// WithPrimary returns a copy of the service whose reads go to the primary
+// database instead of a read replica, for read-your-writes consistency
+func (s *TransactionService) WithPrimary() *TransactionService {
+	clone := *s
+	clone.repo = s.repo.WithPrimary()
+	return &clone
+}
+
+// SetTimeouts replaces the per-operation database timeouts
+func (s *TransactionService) SetTimeouts(timeouts Timeouts) {
+	s.timeouts = timeouts
+}
+
+// SetMetrics records transaction creations and transitions into m
+func (s *TransactionService) SetMetrics(m *metrics.Metrics) {
+	s.metrics = m
+}
+
+// withTimeout returns ctx limited by timeout
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// SetFailureCodes replaces the catalog of accepted failure codes
+func (s *TransactionService) SetFailureCodes(codes []string) {
+	failureCodes := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		failureCodes[code] = true
+	}
+	s.failureCodes = failureCodes
+}
+
+// ValidateFailure checks the failure code of an update against the catalog
+func (s *TransactionService) ValidateFailure(req *models.TransactionUpdateRequest) error {
+	if req.Status != models.StatusFailed {
+		if req.FailureCode != "" || req.FailureMessage != "" {
+			return fieldValidationError("failure_code", "excluded_unless", "failure_code and failure_message are only allowed when status is failed")
+		}
+		return nil
+	}
+
+	if req.FailureCode == "" {
+		return fieldValidationError("failure_code", "required_if", "failure_code is required when status is failed")
+	}
+	if !s.failureCodes[req.FailureCode] {
+		return fieldValidationError("failure_code", "oneof", "unknown failure_code: {0}", req.FailureCode)
+	}
+	return nil
 }
 
 // CreateTransaction creates a new transaction
-func (s *TransactionService) CreateTransaction(req *models.TransactionRequest) (*models.Transaction, error) {
+func (s *TransactionService) CreateTransaction(ctx context.Context, req *models.TransactionRequest) (_ *models.Transaction, err error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.CreateTransaction")
+	defer tracing.End(span, &err)
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
 	transaction := &models.Transaction{
 		UserID: req.UserID,
 		Amount: req.Amount,
 		Status: models.StatusPending,
 	}
 
-	if err := s.db.Create(transaction).Error; err != nil {
-		logrus.WithError(err).Error("Failed to create transaction")
-		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	if err := s.repo.Create(ctx, transaction); err != nil {
+		logging.FromContext(ctx).WithError(err).Error("Failed to create transaction")
+		return nil, repositoryError(err, "failed to create transaction")
 	}
+	s.metrics.TransactionCreated(transaction)
 
-	logrus.WithFields(logrus.Fields{
+	logging.FromContext(ctx).WithFields(logrus.Fields{
 		"transaction_id": transaction.ID,
 		"user_id":        transaction.UserID,
 		"amount":         transaction.Amount,
@@ -42,40 +127,31 @@ func (s *TransactionService) CreateTransaction(req *models.TransactionRequest) (
 }
 
 // GetTransactionByID retrieves a transaction by ID
-func (s *TransactionService) GetTransactionByID(id uint) (*models.Transaction, error) {
-	var transaction models.Transaction
-	if err := s.db.First(&transaction, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("transaction not found")
+func (s *TransactionService) GetTransactionByID(ctx context.Context, id uint) (_ *models.Transaction, err error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.GetTransactionByID")
+	defer tracing.End(span, &err)
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	transaction, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			logging.FromContext(ctx).WithError(err).Error("Failed to get transaction")
 		}
-		logrus.WithError(err).Error("Failed to get transaction")
-		return nil, fmt.Errorf("failed to get transaction: %w", err)
+		return nil, repositoryError(err, "failed to get transaction")
 	}
 
-	return &transaction, nil
+	return transaction, nil
 }
 
 // GetTransactions retrieves transactions with filtering and pagination
-func (s *TransactionService) GetTransactions(query *models.TransactionQuery) (*models.TransactionResponse, error) {
-	var transactions []models.Transaction
-	var total int64
+func (s *TransactionService) GetTransactions(ctx context.Context, query *models.TransactionQuery) (_ *models.TransactionResponse, err error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.GetTransactions")
+	defer tracing.End(span, &err)
 
-	// Build query
-	db := s.db.Model(&models.Transaction{})
-
-	// Apply filters
-	if query.UserID != 0 {
-		db = db.Where("user_id = ?", query.UserID)
-	}
-	if query.Status != "" {
-		db = db.Where("status = ?", query.Status)
-	}
-
-	// Count total records
-	if err := db.Count(&total).Error; err != nil {
-		logrus.WithError(err).Error("Failed to count transactions")
-		return nil, fmt.Errorf("failed to count transactions: %w", err)
-	}
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
 
 	// Set default pagination
 	if query.Limit <= 0 {
@@ -87,10 +163,11 @@ func (s *TransactionService) GetTransactions(query *models.TransactionQuery) (*m
 
 	offset := (query.Page - 1) * query.Limit
 
-	// Get transactions with pagination
-	if err := db.Offset(offset).Limit(query.Limit).Order("created_at DESC").Find(&transactions).Error; err != nil {
-		logrus.WithError(err).Error("Failed to get transactions")
-		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	filter := repository.TransactionFilter{UserID: query.UserID, Status: query.Status}
+	transactions, total, err := s.repo.List(ctx, filter, offset, query.Limit)
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).Error("Failed to get transactions")
+		return nil, repositoryError(err, "failed to get transactions")
 	}
 
 	totalPages := int(math.Ceil(float64(total) / float64(query.Limit)))
@@ -107,131 +184,555 @@ func (s *TransactionService) GetTransactions(query *models.TransactionQuery) (*m
 }
 
 // UpdateTransaction updates a transaction status
-func (s *TransactionService) UpdateTransaction(id uint, req *models.TransactionUpdateRequest) (*models.Transaction, error) {
-	var transaction models.Transaction
-	if err := s.db.First(&transaction, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("transaction not found")
-		}
-		return nil, fmt.Errorf("failed to get transaction: %w", err)
+func (s *TransactionService) UpdateTransaction(ctx context.Context, id uint, req *models.TransactionUpdateRequest) (_ *models.Transaction, err error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.UpdateTransaction")
+	defer tracing.End(span, &err)
+
+	if err := s.ValidateFailure(req); err != nil {
+		return nil, err
 	}
 
-	transaction.Status = req.Status
-	if err := s.db.Save(&transaction).Error; err != nil {
-		logrus.WithError(err).Error("Failed to update transaction")
-		return nil, fmt.Errorf("failed to update transaction: %w", err)
+	ctx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	var previous models.TransactionStatus
+	transaction, err := s.repo.Update(ctx, id, func(transaction *models.Transaction) error {
+		previous = transaction.Status
+		transaction.Status = req.Status
+		transaction.FailureCode = req.FailureCode
+		transaction.FailureMessage = req.FailureMessage
+		return nil
+	})
+	if err != nil {
+		var svcErr *Error
+		if errors.As(err, &svcErr) {
+			return nil, svcErr
+		}
+		if !errors.Is(err, repository.ErrNotFound) {
+			logging.FromContext(ctx).WithError(err).Error("Failed to update transaction")
+		}
+		return nil, repositoryError(err, "failed to update transaction")
+	}
+	if previous != transaction.Status {
+		s.metrics.TransactionTransitioned(previous, transaction)
 	}
 
-	logrus.WithFields(logrus.Fields{
+	logging.FromContext(ctx).WithFields(logrus.Fields{
 		"transaction_id": transaction.ID,
 		"new_status":     transaction.Status,
+		"failure_code":   transaction.FailureCode,
 	}).Info("Transaction updated successfully")
 
-	return &transaction, nil
+	return transaction, nil
 }
 
 // DeleteTransaction soft deletes a transaction
-func (s *TransactionService) DeleteTransaction(id uint) error {
-	var transaction models.Transaction
-	if err := s.db.First(&transaction, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return fmt.Errorf("transaction not found")
-		}
-		return fmt.Errorf("failed to get transaction: %w", err)
-	}
+func (s *TransactionService) DeleteTransaction(ctx context.Context, id uint) (err error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.DeleteTransaction")
+	defer tracing.End(span, &err)
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
 
-	if err := s.db.Delete(&transaction).Error; err != nil {
-		logrus.WithError(err).Error("Failed to delete transaction")
-		return fmt.Errorf("failed to delete transaction: %w", err)
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			logging.FromContext(ctx).WithError(err).Error("Failed to delete transaction")
+		}
+		return repositoryError(err, "failed to delete transaction")
 	}
 
-	logrus.WithField("transaction_id", id).Info("Transaction deleted successfully")
+	logging.FromContext(ctx).WithField("transaction_id", id).Info("Transaction deleted successfully")
 	return nil
 }
 
+// dashboardMetrics holds the numeric metrics of a dashboard summary
+type dashboardMetrics struct {
+	totalSuccessInPeriod     int64
+	averageAmountPerUser     float64
+	averageTransactionAmount float64
+	totalTransactions        int64
+	totalAmount              float64
+	totalAmountInPeriod      float64
+	statusDistribution       map[string]int64
+}
+
 // GetDashboardSummary retrieves dashboard summary data
-func (s *TransactionService) GetDashboardSummary() (*models.DashboardSummary, error) {
-	var summary models.DashboardSummary
+func (s *TransactionService) GetDashboardSummary(ctx context.Context, query *models.DashboardQuery) (_ *models.DashboardSummary, err error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.GetDashboardSummary")
+	defer tracing.End(span, &err)
 
-	// Get today's date range
-	today := time.Now().UTC().Truncate(24 * time.Hour)
-	tomorrow := today.Add(24 * time.Hour)
+	if query == nil {
+		query = &models.DashboardQuery{}
+	}
 
-	// Total successful transactions today
-	var totalSuccessToday int64
-	if err := s.db.Model(&models.Transaction{}).
-		Where("status = ? AND created_at >= ? AND created_at < ?", models.StatusSuccess, today, tomorrow).
-		Count(&totalSuccessToday).Error; err != nil {
-		return nil, fmt.Errorf("failed to count today's successful transactions: %w", err)
+	ctx, cancel := withTimeout(ctx, s.timeouts.Analytics)
+	defer cancel()
+
+	// Resolve the reporting period, defaulting to today
+	from := time.Now().UTC().Truncate(24 * time.Hour)
+	to := from.Add(24 * time.Hour)
+	var overall repository.TransactionFilter
+	if query.From != nil {
+		from = query.From.UTC()
+		to = time.Now().UTC()
+		if query.To != nil {
+			to = query.To.UTC()
+		}
+		overall = createdBetween(from, to)
 	}
-	summary.TotalSuccessToday = totalSuccessToday
 
-	// Total transactions
-	var totalTransactions int64
-	if err := s.db.Model(&models.Transaction{}).Count(&totalTransactions).Error; err != nil {
-		return nil, fmt.Errorf("failed to count total transactions: %w", err)
+	metrics, err := s.dashboardMetrics(ctx, from, to, overall)
+	if err != nil {
+		return nil, err
 	}
-	summary.TotalTransactions = totalTransactions
 
-	// Average amount per user
-	var avgResult struct {
-		AvgAmount float64
+	summary := models.DashboardSummary{
+		PeriodFrom:               from,
+		PeriodTo:                 to,
+		TotalSuccessToday:        metrics.totalSuccessInPeriod,
+		AverageAmountPerUser:     metrics.averageAmountPerUser,
+		AverageTransactionAmount: metrics.averageTransactionAmount,
+		TotalTransactions:        metrics.totalTransactions,
+		TotalAmount:              metrics.totalAmount,
+		TotalAmountToday:         metrics.totalAmountInPeriod,
+		StatusDistribution:       metrics.statusDistribution,
 	}
-	if err := s.db.Model(&models.Transaction{}).
-		Select("AVG(amount) as avg_amount").
-		Where("status = ?", models.StatusSuccess).
-		Scan(&avgResult).Error; err != nil {
-		return nil, fmt.Errorf("failed to calculate average amount: %w", err)
+
+	// Recent transactions (latest 10)
+	recentTransactions, _, err := s.repo.List(ctx, overall, 0, 10)
+	if err != nil {
+		return nil, repositoryError(err, "failed to get recent transactions")
 	}
-	summary.AverageAmountPerUser = avgResult.AvgAmount
+	summary.RecentTransactions = recentTransactions
 
-	// Total amount (all successful transactions)
-	var totalAmountResult struct {
-		TotalAmount float64
+	if query.TopUsers > 0 {
+		topUsersQuery := models.TopUsersQuery{
+			By:     models.TopUsersByAmount,
+			Status: models.StatusSuccess,
+			Limit:  query.TopUsers,
+		}
+		if query.From != nil {
+			topUsersQuery.From = &from
+			topUsersQuery.To = &to
+		}
+		topUsers, err := s.topUsers(ctx, &topUsersQuery)
+		if err != nil {
+			return nil, err
+		}
+		summary.TopUsers = topUsers.Users
 	}
-	if err := s.db.Model(&models.Transaction{}).
-		Select("SUM(amount) as total_amount").
-		Where("status = ?", models.StatusSuccess).
-		Scan(&totalAmountResult).Error; err != nil {
-		return nil, fmt.Errorf("failed to calculate total amount: %w", err)
+
+	if query.Compare != "" {
+		comparison, err := s.compareDashboard(ctx, query, from, to, metrics)
+		if err != nil {
+			return nil, err
+		}
+		summary.Comparison = comparison
 	}
-	summary.TotalAmount = totalAmountResult.TotalAmount
 
-	// Total amount today
-	var totalAmountTodayResult struct {
-		TotalAmount float64
+	return &summary, nil
+}
+
+// compareDashboard computes the previous period metrics and compares them with the current ones
+func (s *TransactionService) compareDashboard(ctx context.Context, query *models.DashboardQuery, from, to time.Time, current *dashboardMetrics) (*models.DashboardComparison, error) {
+	var prevFrom, prevTo time.Time
+	switch query.Compare {
+	case models.ComparePreviousPeriod:
+		prevFrom = from.Add(-to.Sub(from))
+		prevTo = from
+	case models.ComparePreviousYear:
+		prevFrom = from.AddDate(-1, 0, 0)
+		prevTo = to.AddDate(-1, 0, 0)
+	default:
+		return nil, fieldValidationError("compare", "oneof", "invalid compare mode: {0}", string(query.Compare))
 	}
-	if err := s.db.Model(&models.Transaction{}).
-		Select("SUM(amount) as total_amount").
-		Where("status = ? AND created_at >= ? AND created_at < ?", models.StatusSuccess, today, tomorrow).
-		Scan(&totalAmountTodayResult).Error; err != nil {
-		return nil, fmt.Errorf("failed to calculate today's total amount: %w", err)
+
+	// Without a custom range the overall metrics are all-time, so the
+	// previous value is the cumulative value as of the previous period end
+	overall := createdBetween(prevFrom, prevTo)
+	if query.From == nil {
+		overall = repository.TransactionFilter{To: &prevTo}
 	}
-	summary.TotalAmountToday = totalAmountTodayResult.TotalAmount
 
-	// Status distribution
-	var statusResults []struct {
-		Status string
-		Count  int64
+	previous, err := s.dashboardMetrics(ctx, prevFrom, prevTo, overall)
+	if err != nil {
+		return nil, err
 	}
-	if err := s.db.Model(&models.Transaction{}).
-		Select("status, COUNT(*) as count").
-		Group("status").
-		Scan(&statusResults).Error; err != nil {
-		return nil, fmt.Errorf("failed to get status distribution: %w", err)
+
+	comparison := &models.DashboardComparison{
+		Mode:         query.Compare,
+		PreviousFrom: prevFrom,
+		PreviousTo:   prevTo,
+		Metrics: map[string]models.MetricComparison{
+			"total_success_today":        compareMetric(float64(current.totalSuccessInPeriod), float64(previous.totalSuccessInPeriod)),
+			"average_amount_per_user":    compareMetric(current.averageAmountPerUser, previous.averageAmountPerUser),
+			"average_transaction_amount": compareMetric(current.averageTransactionAmount, previous.averageTransactionAmount),
+			"total_transactions":         compareMetric(float64(current.totalTransactions), float64(previous.totalTransactions)),
+			"total_amount":               compareMetric(current.totalAmount, previous.totalAmount),
+			"total_amount_today":         compareMetric(current.totalAmountInPeriod, previous.totalAmountInPeriod),
+		},
 	}
 
-	summary.StatusDistribution = make(map[string]int64)
-	for _, result := range statusResults {
-		summary.StatusDistribution[result.Status] = result.Count
+	for _, status := range []models.TransactionStatus{models.StatusPending, models.StatusSuccess, models.StatusFailed} {
+		key := string(status)
+		comparison.Metrics["status_distribution."+key] = compareMetric(
+			float64(current.statusDistribution[key]),
+			float64(previous.statusDistribution[key]),
+		)
 	}
 
-	// Recent transactions (latest 10)
-	var recentTransactions []models.Transaction
-	if err := s.db.Order("created_at DESC").Limit(10).Find(&recentTransactions).Error; err != nil {
-		return nil, fmt.Errorf("failed to get recent transactions: %w", err)
+	return comparison, nil
+}
+
+// dashboardMetrics computes the dashboard metrics. Period metrics cover
+// [from, to), the remaining metrics are restricted by the overall filter.
+func (s *TransactionService) dashboardMetrics(ctx context.Context, from, to time.Time, overall repository.TransactionFilter) (*dashboardMetrics, error) {
+	var metrics dashboardMetrics
+
+	// Successful transactions and amount in period
+	period := createdBetween(from, to)
+	period.Status = models.StatusSuccess
+	periodStats, err := s.repo.AmountStats(ctx, period)
+	if err != nil {
+		return nil, repositoryError(err, "failed to calculate period totals")
 	}
-	summary.RecentTransactions = recentTransactions
+	metrics.totalSuccessInPeriod = periodStats.Count
+	metrics.totalAmountInPeriod = periodStats.Sum
+
+	// Total amount and averages (all successful transactions)
+	successful := overall
+	successful.Status = models.StatusSuccess
+	stats, err := s.repo.AmountStats(ctx, successful)
+	if err != nil {
+		return nil, repositoryError(err, "failed to calculate amounts")
+	}
+	metrics.totalAmount = stats.Sum
+	metrics.averageTransactionAmount = stats.Average()
+	// Average amount per user (average of each user's successful total)
+	metrics.averageAmountPerUser = stats.AveragePerUser()
+
+	// Status distribution and total transactions
+	counts, err := s.repo.CountByStatus(ctx, overall)
+	if err != nil {
+		return nil, repositoryError(err, "failed to get status distribution")
+	}
+
+	metrics.statusDistribution = make(map[string]int64, len(counts))
+	for status, count := range counts {
+		metrics.statusDistribution[string(status)] = count
+		metrics.totalTransactions += count
+	}
+
+	return &metrics, nil
+}
+
+const (
+	defaultDistributionBuckets = 10
+	maxDistributionBuckets     = 100
+)
+
+// GetAmountDistribution retrieves the amount histogram and percentiles.
+// Buckets and percentiles are computed by the repository so rows are never
+// loaded into the service.
+func (s *TransactionService) GetAmountDistribution(ctx context.Context, query *models.DistributionQuery) (_ *models.AmountDistribution, err error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.GetAmountDistribution")
+	defer tracing.End(span, &err)
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Analytics)
+	defer cancel()
+
+	filter := repository.TransactionFilter{UserID: query.UserID, Status: query.Status, From: query.From, To: query.To}
+
+	if query.Scale == "" {
+		query.Scale = models.ScaleLinear
+	}
+	if query.Buckets <= 0 {
+		query.Buckets = defaultDistributionBuckets
+	}
+	if query.Buckets > maxDistributionBuckets {
+		query.Buckets = maxDistributionBuckets
+	}
+
+	stats, err := s.repo.AmountStats(ctx, filter)
+	if err != nil {
+		return nil, repositoryError(err, "failed to get amount statistics")
+	}
+
+	distribution := &models.AmountDistribution{
+		Scale:   query.Scale,
+		Count:   stats.Count,
+		Buckets: []models.AmountBucket{},
+	}
+	if stats.Count == 0 {
+		return distribution, nil
+	}
+
+	distribution.Min = stats.Min
+	distribution.Max = stats.Max
+	if query.Min != nil {
+		distribution.Min = *query.Min
+	}
+	if query.Max != nil {
+		distribution.Max = *query.Max
+	}
+
+	bounds, err := bucketBounds(query.Scale, distribution.Min, distribution.Max, query.Buckets)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.repo.AmountHistogram(ctx, filter, bounds)
+	if err != nil {
+		return nil, repositoryError(err, "failed to get amount buckets")
+	}
+
+	distribution.Buckets = make([]models.AmountBucket, len(bounds)-1)
+	for i := range distribution.Buckets {
+		distribution.Buckets[i].LowerBound = bounds[i]
+		distribution.Buckets[i].UpperBound = bounds[i+1]
+		distribution.Buckets[i].Count = counts[i]
+	}
+
+	// Percentiles (nearest-rank)
+	percentiles := []struct {
+		rank  float64
+		value *float64
+	}{
+		{0.50, &distribution.Percentiles.P50},
+		{0.90, &distribution.Percentiles.P90},
+		{0.95, &distribution.Percentiles.P95},
+		{0.99, &distribution.Percentiles.P99},
+	}
+	for _, p := range percentiles {
+		offset := int(math.Ceil(p.rank*float64(stats.Count))) - 1
+		amount, ok, err := s.repo.AmountAt(ctx, filter, offset)
+		if err != nil {
+			return nil, repositoryError(err, "failed to calculate amount percentile")
+		}
+		if ok {
+			*p.value = amount
+		}
+	}
+
+	return distribution, nil
+}
+
+// bucketBounds returns the n+1 boundaries of n buckets spanning [min, max]
+func bucketBounds(scale models.BucketScale, min, max float64, n int) ([]float64, error) {
+	if max < min {
+		return nil, validationError("invalid amount range: min {0} is greater than max {1}",
+			strconv.FormatFloat(min, 'f', -1, 64), strconv.FormatFloat(max, 'f', -1, 64))
+	}
+	if max == min {
+		return []float64{min, max}, nil
+	}
+
+	bounds := make([]float64, n+1)
+	switch scale {
+	case models.ScaleLinear:
+		width := (max - min) / float64(n)
+		for i := range bounds {
+			bounds[i] = min + width*float64(i)
+		}
+	case models.ScaleLogarithmic:
+		if min <= 0 {
+			return nil, validationError("invalid amount range: logarithmic scale requires a positive min")
+		}
+		ratio := math.Pow(max/min, 1/float64(n))
+		for i := range bounds {
+			bounds[i] = min * math.Pow(ratio, float64(i))
+		}
+	default:
+		return nil, fieldValidationError("scale", "oneof", "invalid bucket scale: {0}", string(scale))
+	}
+	bounds[n] = max
+
+	return bounds, nil
+}
+
+const (
+	defaultTopUsersLimit = 10
+	maxTopUsersLimit     = 100
+)
+
+// GetTopUsers retrieves the users with the highest amount or transaction count
+func (s *TransactionService) GetTopUsers(ctx context.Context, query *models.TopUsersQuery) (_ *models.TopUsersResponse, err error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.GetTopUsers")
+	defer tracing.End(span, &err)
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Analytics)
+	defer cancel()
+
+	return s.topUsers(ctx, query)
+}
+
+// topUsers computes the top users leaderboard
+func (s *TransactionService) topUsers(ctx context.Context, query *models.TopUsersQuery) (*models.TopUsersResponse, error) {
+	filter := repository.TransactionFilter{Status: query.Status, From: query.From, To: query.To}
+
+	if query.By == "" {
+		query.By = models.TopUsersByAmount
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultTopUsersLimit
+	}
+	if query.Limit > maxTopUsersLimit {
+		query.Limit = maxTopUsersLimit
+	}
+
+	if query.By != models.TopUsersByAmount && query.By != models.TopUsersByCount {
+		return nil, fieldValidationError("by", "oneof", "invalid top users metric: {0}", string(query.By))
+	}
+
+	totals, err := s.repo.AmountStats(ctx, filter)
+	if err != nil {
+		return nil, repositoryError(err, "failed to calculate top users totals")
+	}
+
+	users, err := s.repo.UserTotals(ctx, filter, query.By, query.Limit)
+	if err != nil {
+		return nil, repositoryError(err, "failed to get top users")
+	}
+
+	for i := range users {
+		switch query.By {
+		case models.TopUsersByAmount:
+			if totals.Sum != 0 {
+				users[i].Share = users[i].TotalAmount / totals.Sum
+			}
+		case models.TopUsersByCount:
+			if totals.Count != 0 {
+				users[i].Share = float64(users[i].TransactionCount) / float64(totals.Count)
+			}
+		}
+	}
+
+	return &models.TopUsersResponse{
+		By:               query.By,
+		TransactionCount: totals.Count,
+		TotalAmount:      totals.Sum,
+		Users:            users,
+	}, nil
+}
+
+// GetFailureBreakdown retrieves failures by code over time
+func (s *TransactionService) GetFailureBreakdown(ctx context.Context, query *models.FailureQuery) (_ *models.FailureBreakdown, err error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.GetFailureBreakdown")
+	defer tracing.End(span, &err)
+
+	if query.Interval != models.IntervalHour && query.Interval != models.IntervalDay {
+		return nil, fieldValidationError("interval", "oneof", "invalid interval: {0}", string(query.Interval))
+	}
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Analytics)
+	defer cancel()
+
+	series, err := s.repo.FailureSeries(ctx, createdBetween(query.From, query.To), query.Interval)
+	if err != nil {
+		return nil, repositoryError(err, "failed to get failure series")
+	}
+
+	breakdown := &models.FailureBreakdown{
+		Interval: query.Interval,
+		From:     query.From,
+		To:       query.To,
+		ByCode:   make(map[string]int64),
+		Series:   make([]models.FailureBucket, 0, len(series)),
+	}
+
+	for _, result := range series {
+		bucket := models.FailureBucket{
+			Start:             result.Start,
+			TotalTransactions: result.Total,
+			TotalFailed:       result.Failed,
+			FailureRate:       float64(result.Failed) / float64(result.Total),
+			ByCode:            make(map[string]int64, len(result.ByCode)),
+		}
+		for code, count := range result.ByCode {
+			if code == "" {
+				code = "unknown"
+			}
+			bucket.ByCode[code] += count
+			breakdown.ByCode[code] += count
+		}
+		breakdown.Series = append(breakdown.Series, bucket)
+		breakdown.TotalTransactions += result.Total
+		breakdown.TotalFailed += result.Failed
+	}
+	if breakdown.TotalTransactions > 0 {
+		breakdown.FailureRate = float64(breakdown.TotalFailed) / float64(breakdown.TotalTransactions)
+	}
+
+	return breakdown, nil
+}
+
+// createdBetween filters transactions created in [from, to)
+func createdBetween(from, to time.Time) repository.TransactionFilter {
+	return repository.TransactionFilter{From: &from, To: &to}
+}
+
+// compareMetric compares a metric value with its previous value
+func compareMetric(current, previous float64) models.MetricComparison {
+	comparison := models.MetricComparison{
+		Current:  current,
+		Previous: previous,
+		Delta:    current - previous,
+	}
+	if previous != 0 {
+		percentChange := (current - previous) / previous * 100
+		comparison.PercentChange = &percentChange
+	}
+	return comparison
+}
+
+// GetUserSummary retrieves transaction statistics for a single user
+func (s *TransactionService) GetUserSummary(ctx context.Context, userID uint) (_ *models.UserSummary, err error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.GetUserSummary")
+	defer tracing.End(span, &err)
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Analytics)
+	defer cancel()
+
+	summary := models.UserSummary{
+		UserID:             userID,
+		StatusDistribution: make(map[string]int64),
+	}
+
+	// Status distribution and transaction count
+	filter := repository.TransactionFilter{UserID: userID}
+	counts, err := s.repo.CountByStatus(ctx, filter)
+	if err != nil {
+		return nil, repositoryError(err, "failed to get user status distribution")
+	}
+
+	for status, count := range counts {
+		summary.StatusDistribution[string(status)] = count
+		summary.TransactionCount += count
+	}
+
+	if summary.TransactionCount == 0 {
+		return &summary, nil
+	}
+
+	summary.SuccessRate = float64(summary.StatusDistribution[string(models.StatusSuccess)]) / float64(summary.TransactionCount)
+
+	// Total and average amount (successful transactions only)
+	successful := filter
+	successful.Status = models.StatusSuccess
+	stats, err := s.repo.AmountStats(ctx, successful)
+	if err != nil {
+		return nil, repositoryError(err, "failed to calculate user amounts")
+	}
+	summary.TotalAmount = stats.Sum
+	summary.AverageAmount = stats.Average()
+
+	// First and last transaction time
+	first, last, err := s.repo.CreatedAtRange(ctx, filter)
+	if err != nil {
+		return nil, repositoryError(err, "failed to get user activity range")
+	}
+	summary.FirstTransactionAt = first
+	summary.LastTransactionAt = last
 
 	return &summary, nil
-}
\ No newline at end of file
+}
@@ -1,14 +1,31 @@
 package services
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
+	"transaction-api/internal/logging"
+	"transaction-api/internal/metrics"
 	"transaction-api/internal/models"
+	"transaction-api/internal/repository"
 
 	"github.com/glebarez/sqlite"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
 )
 
 type TransactionServiceTestSuite struct {
@@ -23,7 +40,7 @@ func (suite *TransactionServiceTestSuite) SetupTest() {
 	suite.Require().NoError(err)
 
 	// Auto migrate the schema
-	err = db.AutoMigrate(&models.Transaction{})
+	err = db.AutoMigrate(&models.Transaction{}, &models.AuditEvent{})
 	suite.Require().NoError(err)
 
 	suite.db = db
@@ -42,7 +59,7 @@ func (suite *TransactionServiceTestSuite) TestCreateTransaction() {
 		Amount: 100.50,
 	}
 
-	transaction, err := suite.service.CreateTransaction(req)
+	transaction, err := suite.service.CreateTransaction(context.Background(), req)
 
 	assert.NoError(suite.T(), err)
 	assert.NotNil(suite.T(), transaction)
@@ -63,7 +80,7 @@ func (suite *TransactionServiceTestSuite) TestGetTransactionByID() {
 	suite.Require().NoError(err)
 
 	// Test getting existing transaction
-	result, err := suite.service.GetTransactionByID(transaction.ID)
+	result, err := suite.service.GetTransactionByID(context.Background(), transaction.ID)
 	assert.NoError(suite.T(), err)
 	assert.NotNil(suite.T(), result)
 	assert.Equal(suite.T(), transaction.ID, result.ID)
@@ -71,7 +88,7 @@ func (suite *TransactionServiceTestSuite) TestGetTransactionByID() {
 	assert.Equal(suite.T(), transaction.Amount, result.Amount)
 
 	// Test getting non-existing transaction
-	result, err = suite.service.GetTransactionByID(999)
+	result, err = suite.service.GetTransactionByID(context.Background(), 999)
 	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), result)
 	assert.Contains(suite.T(), err.Error(), "transaction not found")
@@ -96,7 +113,7 @@ func (suite *TransactionServiceTestSuite) TestGetTransactions() {
 		Page:  1,
 		Limit: 10,
 	}
-	response, err := suite.service.GetTransactions(query)
+	response, err := suite.service.GetTransactions(context.Background(), query)
 	assert.NoError(suite.T(), err)
 	assert.NotNil(suite.T(), response)
 	assert.Equal(suite.T(), int64(4), response.Total)
@@ -108,7 +125,7 @@ func (suite *TransactionServiceTestSuite) TestGetTransactions() {
 		Page:   1,
 		Limit:  10,
 	}
-	response, err = suite.service.GetTransactions(query)
+	response, err = suite.service.GetTransactions(context.Background(), query)
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), int64(2), response.Total)
 	assert.Equal(suite.T(), 2, len(response.Data))
@@ -119,7 +136,7 @@ func (suite *TransactionServiceTestSuite) TestGetTransactions() {
 		Page:   1,
 		Limit:  10,
 	}
-	response, err = suite.service.GetTransactions(query)
+	response, err = suite.service.GetTransactions(context.Background(), query)
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), int64(2), response.Total)
 	assert.Equal(suite.T(), 2, len(response.Data))
@@ -129,7 +146,7 @@ func (suite *TransactionServiceTestSuite) TestGetTransactions() {
 		Page:  1,
 		Limit: 2,
 	}
-	response, err = suite.service.GetTransactions(query)
+	response, err = suite.service.GetTransactions(context.Background(), query)
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), int64(4), response.Total)
 	assert.Equal(suite.T(), 2, len(response.Data))
@@ -150,16 +167,48 @@ func (suite *TransactionServiceTestSuite) TestUpdateTransaction() {
 	updateReq := &models.TransactionUpdateRequest{
 		Status: models.StatusSuccess,
 	}
-	result, err := suite.service.UpdateTransaction(transaction.ID, updateReq)
+	result, err := suite.service.UpdateTransaction(context.Background(), transaction.ID, updateReq)
 	assert.NoError(suite.T(), err)
 	assert.NotNil(suite.T(), result)
 	assert.Equal(suite.T(), models.StatusSuccess, result.Status)
 
 	// Test updating non-existing transaction
-	result, err = suite.service.UpdateTransaction(999, updateReq)
+	result, err = suite.service.UpdateTransaction(context.Background(), 999, updateReq)
 	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), result)
 	assert.Contains(suite.T(), err.Error(), "transaction not found")
+	assert.True(suite.T(), errors.Is(err, ErrNotFound))
+
+	// Test failing a transaction records the failure reason
+	failReq := &models.TransactionUpdateRequest{
+		Status:         models.StatusFailed,
+		FailureCode:    "insufficient_funds",
+		FailureMessage: "Balance too low",
+	}
+	result, err = suite.service.UpdateTransaction(context.Background(), transaction.ID, failReq)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "insufficient_funds", result.FailureCode)
+	assert.Equal(suite.T(), "Balance too low", result.FailureMessage)
+
+	// Test moving away from failed clears the failure reason
+	result, err = suite.service.UpdateTransaction(context.Background(), transaction.ID, updateReq)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), result.FailureCode)
+	assert.Empty(suite.T(), result.FailureMessage)
+
+	// Test failure code is required and checked against the catalog
+	_, err = suite.service.UpdateTransaction(context.Background(), transaction.ID, &models.TransactionUpdateRequest{Status: models.StatusFailed})
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "failure_code is required")
+	assert.True(suite.T(), errors.Is(err, ErrValidation))
+
+	_, err = suite.service.UpdateTransaction(context.Background(), transaction.ID, &models.TransactionUpdateRequest{Status: models.StatusFailed, FailureCode: "cosmic_rays"})
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "unknown failure_code")
+
+	suite.service.SetFailureCodes([]string{"cosmic_rays"})
+	_, err = suite.service.UpdateTransaction(context.Background(), transaction.ID, &models.TransactionUpdateRequest{Status: models.StatusFailed, FailureCode: "cosmic_rays"})
+	assert.NoError(suite.T(), err)
 }
 
 func (suite *TransactionServiceTestSuite) TestDeleteTransaction() {
@@ -173,7 +222,7 @@ func (suite *TransactionServiceTestSuite) TestDeleteTransaction() {
 	suite.Require().NoError(err)
 
 	// Test deleting existing transaction
-	err = suite.service.DeleteTransaction(transaction.ID)
+	err = suite.service.DeleteTransaction(context.Background(), transaction.ID)
 	assert.NoError(suite.T(), err)
 
 	// Verify transaction is soft deleted
@@ -183,9 +232,10 @@ func (suite *TransactionServiceTestSuite) TestDeleteTransaction() {
 	assert.NotNil(suite.T(), deletedTransaction.DeletedAt)
 
 	// Test deleting non-existing transaction
-	err = suite.service.DeleteTransaction(999)
+	err = suite.service.DeleteTransaction(context.Background(), 999)
 	assert.Error(suite.T(), err)
 	assert.Contains(suite.T(), err.Error(), "transaction not found")
+	assert.True(suite.T(), errors.Is(err, ErrNotFound))
 }
 
 func (suite *TransactionServiceTestSuite) TestGetDashboardSummary() {
@@ -207,7 +257,7 @@ func (suite *TransactionServiceTestSuite) TestGetDashboardSummary() {
 		suite.Require().NoError(err)
 	}
 
-	summary, err := suite.service.GetDashboardSummary()
+	summary, err := suite.service.GetDashboardSummary(context.Background(), &models.DashboardQuery{})
 	assert.NoError(suite.T(), err)
 	assert.NotNil(suite.T(), summary)
 
@@ -217,8 +267,11 @@ func (suite *TransactionServiceTestSuite) TestGetDashboardSummary() {
 	// Check total successful transactions today (should be 2)
 	assert.Equal(suite.T(), int64(2), summary.TotalSuccessToday)
 
-	// Check average amount per user (should be average of successful transactions: (100+200+300)/3 = 200)
-	assert.Equal(suite.T(), 200.0, summary.AverageAmountPerUser)
+	// Check average transaction amount (average of successful transactions: (100+200+300)/3 = 200)
+	assert.Equal(suite.T(), 200.0, summary.AverageTransactionAmount)
+
+	// Check average amount per user (average of per-user successful totals: (300+300)/2 = 300)
+	assert.Equal(suite.T(), 300.0, summary.AverageAmountPerUser)
 
 	// Check total amount (successful transactions: 100+200+300 = 600)
 	assert.Equal(suite.T(), 600.0, summary.TotalAmount)
@@ -235,6 +288,441 @@ func (suite *TransactionServiceTestSuite) TestGetDashboardSummary() {
 	assert.Equal(suite.T(), 5, len(summary.RecentTransactions))
 }
 
+func (suite *TransactionServiceTestSuite) TestGetDashboardSummaryComparison() {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	yesterday := today.Add(-24 * time.Hour)
+
+	transactions := []models.Transaction{
+		{UserID: 1, Amount: 100.0, Status: models.StatusSuccess, CreatedAt: today.Add(time.Hour)},
+		{UserID: 1, Amount: 300.0, Status: models.StatusSuccess, CreatedAt: today.Add(2 * time.Hour)},
+		{UserID: 2, Amount: 200.0, Status: models.StatusSuccess, CreatedAt: yesterday.Add(time.Hour)},
+		{UserID: 2, Amount: 50.0, Status: models.StatusFailed, CreatedAt: yesterday.Add(2 * time.Hour)},
+	}
+
+	for i := range transactions {
+		err := suite.db.Create(&transactions[i]).Error
+		suite.Require().NoError(err)
+	}
+
+	// Default period (today) compared with yesterday
+	summary, err := suite.service.GetDashboardSummary(context.Background(), &models.DashboardQuery{Compare: models.ComparePreviousPeriod})
+	assert.NoError(suite.T(), err)
+	suite.Require().NotNil(summary.Comparison)
+	assert.Equal(suite.T(), yesterday, summary.Comparison.PreviousFrom)
+	assert.Equal(suite.T(), today, summary.Comparison.PreviousTo)
+
+	metric := summary.Comparison.Metrics["total_amount_today"]
+	assert.Equal(suite.T(), 400.0, metric.Current)
+	assert.Equal(suite.T(), 200.0, metric.Previous)
+	assert.Equal(suite.T(), 200.0, metric.Delta)
+	suite.Require().NotNil(metric.PercentChange)
+	assert.Equal(suite.T(), 100.0, *metric.PercentChange)
+
+	// All-time metrics compare against the cumulative value at the previous period end
+	metric = summary.Comparison.Metrics["total_transactions"]
+	assert.Equal(suite.T(), 4.0, metric.Current)
+	assert.Equal(suite.T(), 2.0, metric.Previous)
+
+	metric = summary.Comparison.Metrics["status_distribution.failed"]
+	assert.Equal(suite.T(), 1.0, metric.Current)
+	assert.Equal(suite.T(), 1.0, metric.Previous)
+	assert.Equal(suite.T(), 0.0, *metric.PercentChange)
+
+	// Custom range restricts every metric to the range
+	from := yesterday
+	to := today
+	summary, err = suite.service.GetDashboardSummary(context.Background(), &models.DashboardQuery{From: &from, To: &to, Compare: models.ComparePreviousYear})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(2), summary.TotalTransactions)
+	assert.Equal(suite.T(), 200.0, summary.TotalAmountToday)
+	assert.Equal(suite.T(), 2, len(summary.RecentTransactions))
+	suite.Require().NotNil(summary.Comparison)
+	assert.Equal(suite.T(), from.AddDate(-1, 0, 0), summary.Comparison.PreviousFrom)
+
+	metric = summary.Comparison.Metrics["total_amount"]
+	assert.Equal(suite.T(), 200.0, metric.Current)
+	assert.Equal(suite.T(), 0.0, metric.Previous)
+	assert.Nil(suite.T(), metric.PercentChange)
+}
+
+func (suite *TransactionServiceTestSuite) TestGetAmountDistribution() {
+	// Amounts 1..100, user 2 owns the failed ones above 90
+	for i := 1; i <= 100; i++ {
+		transaction := models.Transaction{UserID: 1, Amount: float64(i), Status: models.StatusSuccess}
+		if i > 90 {
+			transaction.UserID = 2
+			transaction.Status = models.StatusFailed
+		}
+		err := suite.db.Create(&transaction).Error
+		suite.Require().NoError(err)
+	}
+
+	// Linear buckets over the whole set
+	distribution, err := suite.service.GetAmountDistribution(context.Background(), &models.DistributionQuery{Buckets: 4})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(100), distribution.Count)
+	assert.Equal(suite.T(), 1.0, distribution.Min)
+	assert.Equal(suite.T(), 100.0, distribution.Max)
+	suite.Require().Equal(4, len(distribution.Buckets))
+
+	var bucketTotal int64
+	for _, bucket := range distribution.Buckets {
+		bucketTotal += bucket.Count
+	}
+	assert.Equal(suite.T(), int64(100), bucketTotal)
+	assert.Equal(suite.T(), 1.0, distribution.Buckets[0].LowerBound)
+	assert.Equal(suite.T(), 100.0, distribution.Buckets[3].UpperBound)
+
+	assert.Equal(suite.T(), 50.0, distribution.Percentiles.P50)
+	assert.Equal(suite.T(), 90.0, distribution.Percentiles.P90)
+	assert.Equal(suite.T(), 95.0, distribution.Percentiles.P95)
+	assert.Equal(suite.T(), 99.0, distribution.Percentiles.P99)
+
+	// Logarithmic buckets: [1,10), [10,100]
+	distribution, err = suite.service.GetAmountDistribution(context.Background(), &models.DistributionQuery{Buckets: 2, Scale: models.ScaleLogarithmic})
+	assert.NoError(suite.T(), err)
+	suite.Require().Equal(2, len(distribution.Buckets))
+	assert.InDelta(suite.T(), 10.0, distribution.Buckets[0].UpperBound, 1e-9)
+	assert.Equal(suite.T(), int64(9), distribution.Buckets[0].Count)
+	assert.Equal(suite.T(), int64(91), distribution.Buckets[1].Count)
+
+	// Filters apply to buckets and percentiles
+	distribution, err = suite.service.GetAmountDistribution(context.Background(), &models.DistributionQuery{Status: models.StatusFailed})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(10), distribution.Count)
+	assert.Equal(suite.T(), 91.0, distribution.Min)
+	assert.Equal(suite.T(), 95.0, distribution.Percentiles.P50)
+
+	// Empty result
+	distribution, err = suite.service.GetAmountDistribution(context.Background(), &models.DistributionQuery{UserID: 999})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(0), distribution.Count)
+	assert.Empty(suite.T(), distribution.Buckets)
+}
+
+func (suite *TransactionServiceTestSuite) TestGetTopUsers() {
+	transactions := []models.Transaction{
+		{UserID: 1, Amount: 500.0, Status: models.StatusSuccess},
+		{UserID: 2, Amount: 100.0, Status: models.StatusSuccess},
+		{UserID: 2, Amount: 100.0, Status: models.StatusSuccess},
+		{UserID: 2, Amount: 200.0, Status: models.StatusSuccess},
+		{UserID: 3, Amount: 50.0, Status: models.StatusFailed},
+		{UserID: 3, Amount: 60.0, Status: models.StatusFailed},
+		{UserID: 1, Amount: 70.0, Status: models.StatusFailed},
+	}
+
+	for i := range transactions {
+		err := suite.db.Create(&transactions[i]).Error
+		suite.Require().NoError(err)
+	}
+
+	// Top users by successful amount
+	response, err := suite.service.GetTopUsers(context.Background(), &models.TopUsersQuery{Status: models.StatusSuccess})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), models.TopUsersByAmount, response.By)
+	assert.Equal(suite.T(), 900.0, response.TotalAmount)
+	suite.Require().Equal(2, len(response.Users))
+	assert.Equal(suite.T(), uint(1), response.Users[0].UserID)
+	assert.InDelta(suite.T(), 500.0/900.0, response.Users[0].Share, 1e-9)
+	assert.Equal(suite.T(), uint(2), response.Users[1].UserID)
+	assert.Equal(suite.T(), int64(3), response.Users[1].TransactionCount)
+
+	// Users with the most failures
+	response, err = suite.service.GetTopUsers(context.Background(), &models.TopUsersQuery{By: models.TopUsersByCount, Status: models.StatusFailed, Limit: 1})
+	assert.NoError(suite.T(), err)
+	suite.Require().Equal(1, len(response.Users))
+	assert.Equal(suite.T(), uint(3), response.Users[0].UserID)
+	assert.InDelta(suite.T(), 2.0/3.0, response.Users[0].Share, 1e-9)
+
+	// Dashboard summary includes the top users section on request
+	summary, err := suite.service.GetDashboardSummary(context.Background(), &models.DashboardQuery{TopUsers: 1})
+	assert.NoError(suite.T(), err)
+	suite.Require().Equal(1, len(summary.TopUsers))
+	assert.Equal(suite.T(), uint(1), summary.TopUsers[0].UserID)
+}
+
+func (suite *TransactionServiceTestSuite) TestGetFailureBreakdown() {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	yesterday := today.Add(-24 * time.Hour)
+
+	transactions := []models.Transaction{
+		{UserID: 1, Amount: 100.0, Status: models.StatusSuccess, CreatedAt: yesterday.Add(time.Hour)},
+		{UserID: 1, Amount: 100.0, Status: models.StatusFailed, FailureCode: "timeout", CreatedAt: yesterday.Add(2 * time.Hour)},
+		{UserID: 2, Amount: 100.0, Status: models.StatusFailed, FailureCode: "timeout", CreatedAt: today.Add(time.Hour)},
+		{UserID: 2, Amount: 100.0, Status: models.StatusFailed, FailureCode: "declined", CreatedAt: today.Add(time.Hour)},
+		{UserID: 3, Amount: 100.0, Status: models.StatusFailed, CreatedAt: today.Add(2 * time.Hour)},
+	}
+
+	for i := range transactions {
+		err := suite.db.Create(&transactions[i]).Error
+		suite.Require().NoError(err)
+	}
+
+	breakdown, err := suite.service.GetFailureBreakdown(context.Background(), &models.FailureQuery{
+		From:     yesterday,
+		To:       today.Add(24 * time.Hour),
+		Interval: models.IntervalDay,
+	})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(5), breakdown.TotalTransactions)
+	assert.Equal(suite.T(), int64(4), breakdown.TotalFailed)
+	assert.Equal(suite.T(), 0.8, breakdown.FailureRate)
+	assert.Equal(suite.T(), int64(2), breakdown.ByCode["timeout"])
+	assert.Equal(suite.T(), int64(1), breakdown.ByCode["declined"])
+	assert.Equal(suite.T(), int64(1), breakdown.ByCode["unknown"])
+
+	suite.Require().Equal(2, len(breakdown.Series))
+	assert.Equal(suite.T(), yesterday, breakdown.Series[0].Start)
+	assert.Equal(suite.T(), 0.5, breakdown.Series[0].FailureRate)
+	assert.Equal(suite.T(), int64(1), breakdown.Series[0].ByCode["timeout"])
+	assert.Equal(suite.T(), today, breakdown.Series[1].Start)
+	assert.Equal(suite.T(), int64(3), breakdown.Series[1].TotalFailed)
+
+	// Hourly buckets
+	breakdown, err = suite.service.GetFailureBreakdown(context.Background(), &models.FailureQuery{
+		From:     today,
+		To:       today.Add(24 * time.Hour),
+		Interval: models.IntervalHour,
+	})
+	assert.NoError(suite.T(), err)
+	suite.Require().Equal(2, len(breakdown.Series))
+	assert.Equal(suite.T(), today.Add(time.Hour), breakdown.Series[0].Start)
+	assert.Equal(suite.T(), int64(2), breakdown.Series[0].TotalFailed)
+}
+
+func (suite *TransactionServiceTestSuite) TestGetUserSummary() {
+	now := time.Now().UTC()
+
+	transactions := []models.Transaction{
+		{UserID: 1, Amount: 100.0, Status: models.StatusSuccess, CreatedAt: now.Add(-3 * time.Hour)},
+		{UserID: 1, Amount: 300.0, Status: models.StatusSuccess, CreatedAt: now.Add(-2 * time.Hour)},
+		{UserID: 1, Amount: 500.0, Status: models.StatusFailed, CreatedAt: now.Add(-time.Hour)},
+		{UserID: 1, Amount: 700.0, Status: models.StatusPending, CreatedAt: now},
+		{UserID: 2, Amount: 900.0, Status: models.StatusSuccess, CreatedAt: now},
+	}
+
+	for i := range transactions {
+		err := suite.db.Create(&transactions[i]).Error
+		suite.Require().NoError(err)
+	}
+
+	summary, err := suite.service.GetUserSummary(context.Background(), 1)
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), summary)
+	assert.Equal(suite.T(), uint(1), summary.UserID)
+	assert.Equal(suite.T(), int64(4), summary.TransactionCount)
+	assert.Equal(suite.T(), 0.5, summary.SuccessRate)
+	assert.Equal(suite.T(), 400.0, summary.TotalAmount)
+	assert.Equal(suite.T(), 200.0, summary.AverageAmount)
+	assert.Equal(suite.T(), int64(2), summary.StatusDistribution["success"])
+	assert.Equal(suite.T(), int64(1), summary.StatusDistribution["failed"])
+	assert.Equal(suite.T(), int64(1), summary.StatusDistribution["pending"])
+	suite.Require().NotNil(summary.FirstTransactionAt)
+	suite.Require().NotNil(summary.LastTransactionAt)
+	assert.True(suite.T(), summary.FirstTransactionAt.Equal(transactions[0].CreatedAt))
+	assert.True(suite.T(), summary.LastTransactionAt.Equal(transactions[3].CreatedAt))
+
+	// Test user without transactions
+	summary, err = suite.service.GetUserSummary(context.Background(), 999)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(0), summary.TransactionCount)
+	assert.Nil(suite.T(), summary.FirstTransactionAt)
+	assert.Nil(suite.T(), summary.LastTransactionAt)
+}
+
+func (suite *TransactionServiceTestSuite) TestContextCancellationAndTimeouts() {
+	// A request cancelled by the client aborts its queries
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := suite.service.GetTransactions(ctx, &models.TransactionQuery{})
+	assert.Error(suite.T(), err)
+	assert.True(suite.T(), errors.Is(err, context.Canceled))
+
+	_, err = suite.service.CreateTransaction(ctx, &models.TransactionRequest{UserID: 1, Amount: 100})
+	assert.True(suite.T(), errors.Is(err, context.Canceled))
+
+	// Operations exceeding their timeout report a deadline error
+	suite.service.SetTimeouts(Timeouts{Analytics: time.Nanosecond})
+	_, err = suite.service.GetDashboardSummary(context.Background(), &models.DashboardQuery{})
+	assert.Error(suite.T(), err)
+	assert.True(suite.T(), errors.Is(err, context.DeadlineExceeded))
+
+	// Other operation kinds keep their own timeouts
+	_, err = suite.service.GetTransactions(context.Background(), &models.TransactionQuery{})
+	assert.NoError(suite.T(), err)
+}
+
+func TestTransactionServiceReadReplica(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "primary.db")), &gorm.Config{})
+	require.NoError(t, err)
+	replica, err := gorm.Open(sqlite.Open(filepath.Join(dir, "replica.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Transaction{}, &models.AuditEvent{}))
+	require.NoError(t, replica.AutoMigrate(&models.Transaction{}, &models.AuditEvent{}))
+
+	err = db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: []gorm.Dialector{sqlite.Open(filepath.Join(dir, "replica.db"))},
+	}))
+	require.NoError(t, err)
+
+	service := NewTransactionService(db)
+
+	// Writes go to the primary, which the (lagging) replica has not seen yet
+	transaction, err := service.CreateTransaction(context.Background(), &models.TransactionRequest{UserID: 1, Amount: 100})
+	require.NoError(t, err)
+
+	_, err = service.GetTransactionByID(context.Background(), transaction.ID)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "transaction not found")
+
+	response, err := service.GetTransactions(context.Background(), &models.TransactionQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), response.Total)
+
+	// Reads with read-your-writes consistency go to the primary
+	result, err := service.WithPrimary().GetTransactionByID(context.Background(), transaction.ID)
+	require.NoError(t, err)
+	assert.Equal(t, transaction.ID, result.ID)
+
+	response, err = service.WithPrimary().GetTransactions(context.Background(), &models.TransactionQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), response.Total)
+
+	// Updates read the row from the primary before writing it back
+	_, err = service.UpdateTransaction(context.Background(), transaction.ID, &models.TransactionUpdateRequest{Status: models.StatusSuccess})
+	assert.NoError(t, err)
+}
+
+func TestTransactionServiceMemoryRepository(t *testing.T) {
+	service := NewTransactionServiceWithRepository(repository.NewMemoryTransactionRepository())
+	ctx := context.Background()
+
+	transaction, err := service.CreateTransaction(ctx, &models.TransactionRequest{UserID: 1, Amount: 100})
+	require.NoError(t, err)
+
+	updated, err := service.UpdateTransaction(ctx, transaction.ID, &models.TransactionUpdateRequest{Status: models.StatusSuccess})
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusSuccess, updated.Status)
+
+	summary, err := service.GetUserSummary(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), summary.TransactionCount)
+	assert.Equal(t, 100.0, summary.TotalAmount)
+
+	require.NoError(t, service.DeleteTransaction(ctx, transaction.ID))
+	_, err = service.GetTransactionByID(ctx, transaction.ID)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestTransactionServiceMetrics(t *testing.T) {
+	service := NewTransactionServiceWithRepository(repository.NewMemoryTransactionRepository())
+	m := metrics.New()
+	service.SetMetrics(m)
+	ctx := context.Background()
+
+	transaction, err := service.CreateTransaction(ctx, &models.TransactionRequest{UserID: 1, Amount: 100})
+	require.NoError(t, err)
+	_, err = service.UpdateTransaction(ctx, transaction.ID, &models.TransactionUpdateRequest{Status: models.StatusSuccess})
+	require.NoError(t, err)
+	// Updates keeping the status are not transitions
+	_, err = service.UpdateTransaction(ctx, transaction.ID, &models.TransactionUpdateRequest{Status: models.StatusSuccess})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Contains(t, w.Body.String(), `transaction_api_transactions_created_total{status="pending"} 1`)
+	assert.Contains(t, w.Body.String(), `transaction_api_transaction_transitions_total{from="pending",to="success"} 1`)
+	assert.Contains(t, w.Body.String(), `transaction_api_transaction_amount_count{status="success"} 1`)
+}
+
+func TestTransactionServiceTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	service := NewTransactionServiceWithRepository(repository.NewMemoryTransactionRepository())
+
+	transaction, err := service.CreateTransaction(context.Background(), &models.TransactionRequest{UserID: 1, Amount: 100})
+	require.NoError(t, err)
+	_, err = service.GetTransactionByID(context.Background(), transaction.ID+1)
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "TransactionService.CreateTransaction", spans[0].Name())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, "TransactionService.GetTransactionByID", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
+
+func TestTransactionServiceContextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logrus.SetOutput(&buf)
+	t.Cleanup(func() { logrus.SetOutput(os.Stderr) })
+
+	service := NewTransactionServiceWithRepository(repository.NewMemoryTransactionRepository())
+	ctx := logging.WithFields(context.Background(), logrus.Fields{"request_id": "req-1", "route": "/api/v1/transactions"})
+
+	_, err := service.CreateTransaction(ctx, &models.TransactionRequest{UserID: 1, Amount: 100})
+	require.NoError(t, err)
+
+	assert.Contains(t, buf.String(), "Transaction created successfully")
+	assert.Contains(t, buf.String(), "request_id=req-1")
+	assert.Contains(t, buf.String(), "route=/api/v1/transactions")
+}
+
+// wrappingRepository adds context to repository errors, as decorating
+// repositories do
+type wrappingRepository struct {
+	*repository.MemoryTransactionRepository
+}
+
+func (r wrappingRepository) GetByID(ctx context.Context, id uint) (*models.Transaction, error) {
+	transaction, err := r.MemoryTransactionRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get transaction %d: %w", id, err)
+	}
+	return transaction, nil
+}
+
+func (r wrappingRepository) Update(ctx context.Context, id uint, update func(*models.Transaction) error) (*models.Transaction, error) {
+	transaction, err := r.MemoryTransactionRepository.Update(ctx, id, update)
+	if err != nil {
+		return nil, fmt.Errorf("update transaction %d: %w", id, err)
+	}
+	return transaction, nil
+}
+
+func (r wrappingRepository) Delete(ctx context.Context, id uint) error {
+	if err := r.MemoryTransactionRepository.Delete(ctx, id); err != nil {
+		return fmt.Errorf("delete transaction %d: %w", id, err)
+	}
+	return nil
+}
+
+func TestTransactionServiceWrappedRepositoryErrors(t *testing.T) {
+	var buf bytes.Buffer
+	logrus.SetOutput(&buf)
+	t.Cleanup(func() { logrus.SetOutput(os.Stderr) })
+
+	service := NewTransactionServiceWithRepository(wrappingRepository{repository.NewMemoryTransactionRepository()})
+	ctx := context.Background()
+
+	// Wrapped not found errors are still not found, and not logged as failures
+	_, err := service.GetTransactionByID(ctx, 42)
+	assert.True(t, errors.Is(err, ErrNotFound), err)
+	_, err = service.UpdateTransaction(ctx, 42, &models.TransactionUpdateRequest{Status: models.StatusSuccess})
+	assert.True(t, errors.Is(err, ErrNotFound), err)
+	err = service.DeleteTransaction(ctx, 42)
+	assert.True(t, errors.Is(err, ErrNotFound), err)
+
+	assert.NotContains(t, buf.String(), "level=error")
+}
+
 func TestTransactionServiceTestSuite(t *testing.T) {
 	suite.Run(t, new(TransactionServiceTestSuite))
 }
\ No newline at end of file
@@ -0,0 +1,210 @@
+// Package tlsserver builds the server TLS configuration, with optional
+// client certificate authentication, from certificate files that are
+// reloaded when they change.
+package tlsserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"transaction-api/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Client authentication modes
+const (
+	ClientAuthNone     = "none"
+	ClientAuthOptional = "optional"
+	ClientAuthRequire  = "require"
+)
+
+var minVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	ClientAuthNone:     tls.NoClientCert,
+	ClientAuthOptional: tls.VerifyClientCertIfGiven,
+	ClientAuthRequire:  tls.RequireAndVerifyClientCert,
+}
+
+// Server holds the current certificate and client CAs. Handshakes always
+// use the latest successfully loaded files.
+type Server struct {
+	cfg        config.TLSConfig
+	minVersion uint16
+	clientAuth tls.ClientAuthType
+
+	mu          sync.RWMutex
+	certificate *tls.Certificate
+	clientCAs   *x509.CertPool
+	// versions identifies the loaded files, so unchanged files are not
+	// reloaded
+	versions map[string]fileVersion
+}
+
+// fileVersion is the modification time and size of a file
+type fileVersion struct {
+	modTime time.Time
+	size    int64
+}
+
+// New validates cfg and loads its files
+func New(cfg config.TLSConfig) (*Server, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("TLS requires both a certificate and a key file")
+	}
+
+	minVersion, ok := minVersions[cfg.MinVersion]
+	if !ok {
+		return nil, fmt.Errorf("unsupported minimum TLS version %q, expected 1.2 or 1.3", cfg.MinVersion)
+	}
+	clientAuth, ok := clientAuthTypes[cfg.ClientAuth]
+	if !ok {
+		return nil, fmt.Errorf("unknown client auth mode %q, expected none, optional or require", cfg.ClientAuth)
+	}
+	if clientAuth != tls.NoClientCert && cfg.ClientCAFile == "" {
+		return nil, fmt.Errorf("client auth mode %q requires a client CA file", cfg.ClientAuth)
+	}
+
+	s := &Server{cfg: cfg, minVersion: minVersion, clientAuth: clientAuth}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// TLSConfig returns the configuration for the http.Server. It resolves the
+// certificate and client CAs on every handshake.
+func (s *Server) TLSConfig() *tls.Config {
+	base := &tls.Config{
+		MinVersion: s.minVersion,
+		ClientAuth: s.clientAuth,
+		// http.Server only offers HTTP/2 on the configuration it is given,
+		// so the per-client configurations must offer it themselves
+		NextProtos: []string{"h2", "http/1.1"},
+		// GetCertificate lets http.Server.ServeTLS start without files
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			s.mu.RLock()
+			defer s.mu.RUnlock()
+			return s.certificate, nil
+		},
+	}
+
+	cfg := base.Clone()
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+
+		// Only the client CAs differ from the base configuration. Session
+		// ticket keys are left unset, so the server's own keys are used and
+		// sessions resume across handshakes.
+		forClient := base.Clone()
+		forClient.ClientCAs = s.clientCAs
+		return forClient, nil
+	}
+	return cfg
+}
+
+// Reload loads the certificate, key and client CAs. On error the previously
+// loaded files stay in use.
+func (s *Server) Reload() error {
+	versions, err := s.stat()
+	if err != nil {
+		return err
+	}
+
+	certificate, err := tls.LoadX509KeyPair(s.cfg.CertFile, s.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if s.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(s.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in client CA file %s", s.cfg.ClientCAFile)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.certificate = &certificate
+	s.clientCAs = clientCAs
+	s.versions = versions
+	return nil
+}
+
+// Watch reloads the files whenever they change, checking every interval
+// until ctx is done. Polling also picks up files replaced through symlinks,
+// as mounted Kubernetes secrets are.
+func (s *Server) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.ReloadIfChanged(); err != nil {
+				logrus.WithError(err).Error("Failed to reload TLS certificates, keeping the current ones")
+			}
+		}
+	}
+}
+
+// ReloadIfChanged reloads the files if any of them changed since they were
+// last loaded, and reports whether they were reloaded
+func (s *Server) ReloadIfChanged() (bool, error) {
+	versions, err := s.stat()
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.RLock()
+	changed := false
+	for file, version := range versions {
+		if s.versions[file] != version {
+			changed = true
+		}
+	}
+	s.mu.RUnlock()
+
+	if !changed {
+		return false, nil
+	}
+	if err := s.Reload(); err != nil {
+		return false, err
+	}
+	logrus.WithField("cert_file", s.cfg.CertFile).Info("Reloaded TLS certificates")
+	return true, nil
+}
+
+// stat returns the current versions of the configured files
+func (s *Server) stat() (map[string]fileVersion, error) {
+	versions := make(map[string]fileVersion, 3)
+	for _, file := range []string{s.cfg.CertFile, s.cfg.KeyFile, s.cfg.ClientCAFile} {
+		if file == "" {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat TLS file: %w", err)
+		}
+		versions[file] = fileVersion{modTime: info.ModTime(), size: info.Size()}
+	}
+	return versions, nil
+}
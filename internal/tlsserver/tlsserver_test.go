@@ -0,0 +1,327 @@
+package tlsserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"transaction-api/internal/config"
+	"transaction-api/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCA signs test certificates
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns the PEM certificate and key of a leaf certificate
+func (ca *testCA) issue(t *testing.T, subject pkix.Name, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      subject,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+// testFiles writes a server certificate and key issued by ca, and ca as
+// the client CA
+func testFiles(t *testing.T, ca *testCA, cfg config.TLSConfig) config.TLSConfig {
+	dir := t.TempDir()
+	cfg.CertFile = filepath.Join(dir, "server.crt")
+	cfg.KeyFile = filepath.Join(dir, "server.key")
+	cfg.ClientCAFile = filepath.Join(dir, "client-ca.crt")
+
+	certPEM, keyPEM := ca.issue(t, pkix.Name{CommonName: "server"}, x509.ExtKeyUsageServerAuth)
+	writeFile(t, cfg.CertFile, certPEM)
+	writeFile(t, cfg.KeyFile, keyPEM)
+	writeFile(t, cfg.ClientCAFile, ca.pem)
+	return cfg
+}
+
+// serve starts an HTTPS server the way main does, answering with the caller
+// identity, and returns its address
+func serve(t *testing.T, server *Server) string {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ClientCertificate())
+	router.GET("/", func(c *gin.Context) { c.String(http.StatusOK, c.GetString(middleware.UserKey)) })
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := &http.Server{
+		Handler:   router,
+		TLSConfig: server.TLSConfig(),
+		// Rejected handshakes are expected
+		ErrorLog: log.New(io.Discard, "", 0),
+	}
+	go srv.ServeTLS(listener, "", "")
+	t.Cleanup(func() { srv.Close() })
+
+	return "https://" + listener.Addr().String()
+}
+
+// get requests url with a client trusting ca, presenting the client
+// certificate if given
+func get(t *testing.T, url string, ca *testCA, clientCert *tls.Certificate, maxVersion uint16) (string, *tls.ConnectionState, error) {
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	tlsConfig := &tls.Config{RootCAs: roots, MaxVersion: maxVersion}
+	if clientCert != nil {
+		tlsConfig.Certificates = []tls.Certificate{*clientCert}
+	}
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	defer client.CloseIdleConnections()
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body), resp.TLS, nil
+}
+
+func clientCertificate(t *testing.T, ca *testCA, subject pkix.Name) *tls.Certificate {
+	certPEM, keyPEM := ca.issue(t, subject, x509.ExtKeyUsageClientAuth)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	return &cert
+}
+
+func TestNewValidation(t *testing.T) {
+	ca := newTestCA(t, "test CA")
+	valid := testFiles(t, ca, config.TLSConfig{ClientAuth: ClientAuthRequire, MinVersion: "1.2"})
+
+	_, err := New(valid)
+	require.NoError(t, err)
+
+	for name, mutate := range map[string]func(*config.TLSConfig){
+		"missing key":            func(c *config.TLSConfig) { c.KeyFile = "" },
+		"unknown version":        func(c *config.TLSConfig) { c.MinVersion = "1.0" },
+		"unknown client auth":    func(c *config.TLSConfig) { c.ClientAuth = "sometimes" },
+		"client auth without CA": func(c *config.TLSConfig) { c.ClientCAFile = "" },
+		"missing certificate":    func(c *config.TLSConfig) { c.CertFile = filepath.Join(t.TempDir(), "missing.crt") },
+		"mismatched key": func(c *config.TLSConfig) {
+			_, otherKey := ca.issue(t, pkix.Name{CommonName: "other"}, x509.ExtKeyUsageServerAuth)
+			c.KeyFile = filepath.Join(t.TempDir(), "other.key")
+			writeFile(t, c.KeyFile, otherKey)
+		},
+		"empty CA bundle": func(c *config.TLSConfig) {
+			c.ClientCAFile = filepath.Join(t.TempDir(), "empty.crt")
+			writeFile(t, c.ClientCAFile, []byte("not a certificate"))
+		},
+	} {
+		cfg := valid
+		mutate(&cfg)
+		_, err := New(cfg)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestMinVersion(t *testing.T) {
+	ca := newTestCA(t, "test CA")
+	cfg := testFiles(t, ca, config.TLSConfig{ClientAuth: ClientAuthNone, MinVersion: "1.3"})
+	server, err := New(cfg)
+	require.NoError(t, err)
+	url := serve(t, server)
+
+	_, state, err := get(t, url, ca, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), state.Version)
+
+	_, _, err = get(t, url, ca, nil, tls.VersionTLS12)
+	assert.Error(t, err)
+}
+
+func TestProtocolsAndResumption(t *testing.T) {
+	ca := newTestCA(t, "test CA")
+	cfg := testFiles(t, ca, config.TLSConfig{ClientAuth: ClientAuthOptional, MinVersion: "1.2"})
+	server, err := New(cfg)
+	require.NoError(t, err)
+	url := serve(t, server)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientConfig := &tls.Config{
+		RootCAs:    roots,
+		NextProtos: []string{"h2", "http/1.1"},
+		// TLS 1.2 issues the session ticket during the handshake
+		MaxVersion:         tls.VersionTLS12,
+		ClientSessionCache: tls.NewLRUClientSessionCache(1),
+	}
+	dial := func() tls.ConnectionState {
+		conn, err := tls.Dial("tcp", strings.TrimPrefix(url, "https://"), clientConfig)
+		require.NoError(t, err)
+		defer conn.Close()
+		return conn.ConnectionState()
+	}
+
+	// HTTP/2 is negotiated, and the second connection resumes the session
+	// of the first
+	first := dial()
+	assert.Equal(t, "h2", first.NegotiatedProtocol)
+	assert.False(t, first.DidResume)
+
+	second := dial()
+	assert.Equal(t, "h2", second.NegotiatedProtocol)
+	assert.True(t, second.DidResume)
+
+	// HTTP/1.1 clients are still served
+	clientConfig.NextProtos = []string{"http/1.1"}
+	assert.Equal(t, "http/1.1", dial().NegotiatedProtocol)
+}
+
+func TestClientAuth(t *testing.T) {
+	ca := newTestCA(t, "test CA")
+	otherCA := newTestCA(t, "other CA")
+	subject := pkix.Name{CommonName: "billing", Organization: []string{"Acme"}}
+
+	t.Run("Require", func(t *testing.T) {
+		cfg := testFiles(t, ca, config.TLSConfig{ClientAuth: ClientAuthRequire, MinVersion: "1.2"})
+		server, err := New(cfg)
+		require.NoError(t, err)
+		url := serve(t, server)
+
+		identity, _, err := get(t, url, ca, clientCertificate(t, ca, subject), 0)
+		require.NoError(t, err)
+		assert.Equal(t, "CN=billing,O=Acme", identity)
+
+		_, _, err = get(t, url, ca, nil, 0)
+		assert.Error(t, err)
+		_, _, err = get(t, url, ca, clientCertificate(t, otherCA, subject), 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("Optional", func(t *testing.T) {
+		cfg := testFiles(t, ca, config.TLSConfig{ClientAuth: ClientAuthOptional, MinVersion: "1.2"})
+		server, err := New(cfg)
+		require.NoError(t, err)
+		url := serve(t, server)
+
+		identity, _, err := get(t, url, ca, clientCertificate(t, ca, subject), 0)
+		require.NoError(t, err)
+		assert.Equal(t, "CN=billing,O=Acme", identity)
+
+		identity, _, err = get(t, url, ca, nil, 0)
+		require.NoError(t, err)
+		assert.Empty(t, identity)
+
+		// Certificates of other CAs are not accepted, so the client stays
+		// anonymous
+		identity, _, err = get(t, url, ca, clientCertificate(t, otherCA, subject), 0)
+		require.NoError(t, err)
+		assert.Empty(t, identity)
+	})
+}
+
+func TestReload(t *testing.T) {
+	ca := newTestCA(t, "test CA")
+	cfg := testFiles(t, ca, config.TLSConfig{ClientAuth: ClientAuthRequire, MinVersion: "1.2"})
+	server, err := New(cfg)
+	require.NoError(t, err)
+	url := serve(t, server)
+
+	_, state, err := get(t, url, ca, clientCertificate(t, ca, pkix.Name{CommonName: "client"}), 0)
+	require.NoError(t, err)
+	first := state.PeerCertificates[0].SerialNumber
+
+	reloaded, err := server.ReloadIfChanged()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+
+	// Rotate the server certificate and the client CA
+	newCA := newTestCA(t, "new CA")
+	certPEM, keyPEM := newCA.issue(t, pkix.Name{CommonName: "server"}, x509.ExtKeyUsageServerAuth)
+	writeFile(t, cfg.CertFile, certPEM)
+	writeFile(t, cfg.KeyFile, keyPEM)
+	writeFile(t, cfg.ClientCAFile, newCA.pem)
+	future := time.Now().Add(time.Minute)
+	for _, file := range []string{cfg.CertFile, cfg.KeyFile, cfg.ClientCAFile} {
+		require.NoError(t, os.Chtimes(file, future, future))
+	}
+
+	reloaded, err = server.ReloadIfChanged()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+
+	_, state, err = get(t, url, newCA, clientCertificate(t, newCA, pkix.Name{CommonName: "client"}), 0)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, state.PeerCertificates[0].SerialNumber)
+
+	_, _, err = get(t, url, newCA, clientCertificate(t, ca, pkix.Name{CommonName: "client"}), 0)
+	assert.Error(t, err, "clients of the old CA are rejected")
+
+	// A broken file keeps the current certificate in use
+	writeFile(t, cfg.CertFile, []byte("truncated"))
+	later := future.Add(time.Minute)
+	require.NoError(t, os.Chtimes(cfg.CertFile, later, later))
+
+	_, err = server.ReloadIfChanged()
+	assert.Error(t, err)
+	_, _, err = get(t, url, newCA, clientCertificate(t, newCA, pkix.Name{CommonName: "client"}), 0)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,96 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const querySpanKey = "tracing:span"
+
+// GormPlugin creates a client span for every GORM query, as a child of the
+// span in the statement context
+type GormPlugin struct{}
+
+// NewGormPlugin returns a GORM plugin tracing queries. Register it with
+// db.Use.
+func NewGormPlugin() *GormPlugin {
+	return &GormPlugin{}
+}
+
+// Name implements gorm.Plugin
+func (p *GormPlugin) Name() string {
+	return "tracing"
+}
+
+// Initialize implements gorm.Plugin by wrapping each callback chain with
+// span callbacks
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	register := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", callbacks.Create().Before("gorm:create").Register, callbacks.Create().After("gorm:create").Register},
+		{"query", callbacks.Query().Before("gorm:query").Register, callbacks.Query().After("gorm:query").Register},
+		{"update", callbacks.Update().Before("gorm:update").Register, callbacks.Update().After("gorm:update").Register},
+		{"delete", callbacks.Delete().Before("gorm:delete").Register, callbacks.Delete().After("gorm:delete").Register},
+		{"row", callbacks.Row().Before("gorm:row").Register, callbacks.Row().After("gorm:row").Register},
+		{"raw", callbacks.Raw().Before("gorm:raw").Register, callbacks.Raw().After("gorm:raw").Register},
+	}
+
+	for _, r := range register {
+		if err := r.before("tracing:before_"+r.operation, startQuerySpan(r.operation)); err != nil {
+			return err
+		}
+		if err := r.after("tracing:after_"+r.operation, endQuerySpan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startQuerySpan returns a callback starting the span of operation
+func startQuerySpan(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement.Context == nil {
+			return
+		}
+		_, span := Tracer().Start(db.Statement.Context, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", db.Dialector.Name()),
+				semconv.DBOperation(operation),
+			),
+		)
+		db.InstanceSet(querySpanKey, span)
+	}
+}
+
+// endQuerySpan records the executed statement and ends the query span
+func endQuerySpan(db *gorm.DB) {
+	value, ok := db.InstanceGet(querySpanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	span.SetAttributes(
+		semconv.DBStatement(db.Statement.SQL.String()),
+		semconv.DBSQLTable(db.Statement.Table),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
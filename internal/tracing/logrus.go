@@ -0,0 +1,29 @@
+package tracing
+
+import (
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// LogrusHook adds the trace_id and span_id of the span in an entry's
+// context to its fields. Log with logrus.WithContext for it to apply.
+type LogrusHook struct{}
+
+// Levels implements logrus.Hook
+func (LogrusHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (LogrusHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	spanContext := trace.SpanContextFromContext(entry.Context)
+	if !spanContext.IsValid() {
+		return nil
+	}
+	entry.Data["trace_id"] = spanContext.TraceID().String()
+	entry.Data["span_id"] = spanContext.SpanID().String()
+	return nil
+}
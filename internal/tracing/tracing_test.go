@@ -0,0 +1,150 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"transaction-api/internal/config"
+	"transaction-api/internal/models"
+
+	"github.com/glebarez/sqlite"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// recordSpans installs a tracer provider recording every span for the
+// duration of the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) string {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestStartAndEnd(t *testing.T) {
+	recorder := recordSpans(t)
+
+	func() (err error) {
+		_, span := Start(context.Background(), "ok")
+		defer End(span, &err)
+		return nil
+	}()
+	func() (err error) {
+		_, span := Start(context.Background(), "failed")
+		defer End(span, &err)
+		return errors.New("boom")
+	}()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "boom", spans[1].Status().Description)
+}
+
+func TestGormPlugin(t *testing.T) {
+	recorder := recordSpans(t)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Transaction{}, &models.AuditEvent{}))
+	require.NoError(t, db.Use(NewGormPlugin()))
+
+	ctx, parent := Start(context.Background(), "parent")
+	transaction := models.Transaction{UserID: 1, Amount: 10, Status: models.StatusPending}
+	require.NoError(t, db.WithContext(ctx).Create(&transaction).Error)
+	err = db.WithContext(ctx).First(&models.Transaction{}, 9999).Error
+	require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	create, query := spans[0], spans[1]
+	assert.Equal(t, "gorm.create", create.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), create.Parent().SpanID())
+	assert.Equal(t, "sqlite", spanAttribute(create, "db.system"))
+	assert.Equal(t, "transactions", spanAttribute(create, "db.sql.table"))
+	assert.Contains(t, spanAttribute(create, "db.statement"), "INSERT INTO")
+
+	// A missing record is not a database error
+	assert.Equal(t, "gorm.query", query.Name())
+	assert.Equal(t, codes.Unset, query.Status().Code)
+}
+
+func TestLogrusHook(t *testing.T) {
+	recordSpans(t)
+
+	var buf bytes.Buffer
+	log := logrus.New()
+	log.SetOutput(&buf)
+	log.SetFormatter(&logrus.JSONFormatter{})
+	log.AddHook(LogrusHook{})
+
+	ctx, span := Start(context.Background(), "request")
+	defer span.End()
+
+	log.WithContext(ctx).Info("traced")
+	assert.Contains(t, buf.String(), `"trace_id":"`+span.SpanContext().TraceID().String()+`"`)
+	assert.Contains(t, buf.String(), `"span_id":"`+span.SpanContext().SpanID().String()+`"`)
+
+	buf.Reset()
+	log.WithContext(context.Background()).Info("untraced")
+	log.Info("no context")
+	assert.NotContains(t, buf.String(), "trace_id")
+}
+
+func TestSetup(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	shutdown, err := Setup(context.Background(), config.TracingConfig{Exporter: ExporterNone})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	_, err = Setup(context.Background(), config.TracingConfig{Exporter: "carrier-pigeon"})
+	assert.Error(t, err)
+
+	// The file exporter writes finished spans on shutdown
+	file := filepath.Join(t.TempDir(), "traces.json")
+	shutdown, err = Setup(context.Background(), config.TracingConfig{
+		Exporter:    ExporterFile,
+		ServiceName: "transaction-api-test",
+		SampleRatio: 1,
+		File:        file,
+	})
+	require.NoError(t, err)
+
+	_, span := Start(context.Background(), "exported")
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"Name":"exported"`)
+	assert.Contains(t, string(content), "transaction-api-test")
+}